@@ -1,9 +1,5 @@
 package domain
 
-import (
-	"fmt"
-)
-
 var promotable = map[PieceKind]bool{
 	'P': true, 'L': true, 'N': true, 'S': true, 'B': true, 'R': true,
 }
@@ -56,73 +52,115 @@ func (s *State) hasPawnOnFile(side Color, file int) bool {
 }
 
 // ApplyMoveMinimal: Python版の minimal move 適用をGoで再現する（厳密ルールは後で追加可能）
+// 適用できない手は、盤面・履歴を変更せずにエラーを返す。
+// 新しい手を指すと Redo の履歴は消える。
 func (s *State) ApplyMoveMinimal(kind PieceKind, from *Square, to Square, promote bool, isDrop bool) error {
-	s.ensureHands()
+	if err := s.applyMinimal(kind, from, to, promote, isDrop); err != nil {
+		return err
+	}
+	s.redo = nil
+	return nil
+}
 
-	// undo
-	s.PushHistory()
+// applyMinimal は ApplyMoveMinimal の本体（Redo からも使う）。
+func (s *State) applyMinimal(kind PieceKind, from *Square, to Square, promote bool, isDrop bool) error {
+	s.ensureHands()
 
 	side := s.SideToMove
 
+	// validate (盤面を触る前にすべて確認する)
+	var p *Piece
 	if isDrop {
-		// hand consume
 		if s.Hands[side][kind] <= 0 {
-			return fmt.Errorf("no such piece in hand: %c", kind)
+			return ruleErr(CodeNoPieceInHand, side, kind, nil, &to)
+		}
+	} else {
+		if from == nil {
+			return ruleErr(CodeMissingFrom, side, kind, nil, &to)
+		}
+		p = s.PieceAt(*from)
+		if p == nil {
+			return ruleErr(CodeNoPieceAtFrom, side, kind, from, &to)
+		}
+		if p.Color != side {
+			return ruleErr(CodeWrongColor, side, p.Kind, from, &to)
 		}
+		if promote && !promotable[p.Kind] {
+			return ruleErr(CodeNotPromotable, side, p.Kind, from, &to)
+		}
+	}
+
+	// 千日手判定用に、最初の手の前の局面を記録しておく
+	s.ensurePositions()
+	h := s.Hash()
+
+	if isDrop {
+		// hand consume
+		n := s.Hands[side][kind]
+		h ^= zobristHandKey(side, kind, n) ^ zobristHandKey(side, kind, n-1)
 		s.Hands[side][kind]--
 		if s.Hands[side][kind] <= 0 {
 			delete(s.Hands[side], kind)
 		}
 
-		s.SetPieceAt(to, &Piece{Color: side, Kind: kind, Prom: false})
-		s.Moves = append(s.Moves, Move{
-			IsDrop:  true,
-			Kind:    kind,
-			From:    nil,
-			To:      to,
-			Promote: false,
-		})
+		old := s.PieceAt(to)
+		if old != nil {
+			h ^= zobristPieceKey(to, old)
+		}
+		np := Piece{Color: side, Kind: kind, Prom: false}
+		h ^= zobristPieceKey(to, &np)
+		s.SetPieceAt(to, &np)
 		s.toggleSide()
+		mv := Move{
+			IsDrop:     true,
+			Kind:       kind,
+			From:       nil,
+			To:         to,
+			Promote:    false,
+			GivesCheck: s.IsInCheck(s.SideToMove),
+		}
+		s.Moves = append(s.Moves, mv)
+		s.history = append(s.history, undoRecord{move: mv, side: side, overwritten: old})
+		s.pushPosition(h^zobristSide, mv.GivesCheck)
 		return nil
 	}
 
-	if from == nil {
-		return fmt.Errorf("from is nil for non-drop move")
-	}
-
-	p := s.PieceAt(*from)
-	if p == nil {
-		return fmt.Errorf("no piece at from")
-	}
-	if p.Color != side {
-		return fmt.Errorf("piece color mismatch")
-	}
-
 	// capture -> add to hand (unpromoted kind)
 	dest := s.PieceAt(to)
 	if dest != nil {
-		s.Hands[side][dest.Kind] = s.Hands[side][dest.Kind] + 1
+		n := s.Hands[side][dest.Kind]
+		h ^= zobristPieceKey(to, dest)
+		h ^= zobristHandKey(side, dest.Kind, n) ^ zobristHandKey(side, dest.Kind, n+1)
+		s.Hands[side][dest.Kind] = n + 1
 	}
 
 	// move
-	s.SetPieceAt(*from, nil)
+	h ^= zobristPieceKey(*from, p)
 	np := *p
 	if promote {
-		if !promotable[np.Kind] {
-			return fmt.Errorf("not promotable: %c", np.Kind)
-		}
 		np.Prom = true
 	}
+	s.SetPieceAt(*from, nil)
+	h ^= zobristPieceKey(to, &np)
 	s.SetPieceAt(to, &np)
 
-	s.Moves = append(s.Moves, Move{
-		IsDrop:  false,
-		Kind:    kind,
-		From:    from,
-		To:      to,
-		Promote: promote,
-	})
 	s.toggleSide()
+	mv := Move{
+		IsDrop:      false,
+		Kind:        kind,
+		From:        from,
+		To:          to,
+		Promote:     promote,
+		WasPromoted: p.Prom,
+		GivesCheck:  s.IsInCheck(s.SideToMove),
+	}
+	if dest != nil {
+		captured := *dest
+		mv.Captured = &captured
+	}
+	s.Moves = append(s.Moves, mv)
+	s.history = append(s.history, undoRecord{move: mv, side: side})
+	s.pushPosition(h^zobristSide, mv.GivesCheck)
 	return nil
 }
 
@@ -130,6 +168,10 @@ func (s *State) ApplyMoveMinimal(kind PieceKind, from *Square, to Square, promot
 // - 打ちは空マス必須
 // - 自駒への着手禁止
 // - 移動元に駒が存在し、手番と一致すること
+// - 駒の動きとして到達できること（走り駒の遮りを含む）
+// - 指した後に自玉へ王手がかかっていないこと（ErrKingInCheck）
+// - 打ち歩詰めでないこと（ErrPawnDropMate）
+// - 条件（協力詰・ばか自殺詰）で先手の手が王手であること（ErrMustCheck）
 // - Promote は「成れる駒」だけ（※成れる条件の厳密チェックは後で拡張）
 func (st *State) ApplyMoveStrict(kind PieceKind, from *Square, to Square, promote bool, isDrop bool) error {
 	st.ensureHands()
@@ -137,103 +179,107 @@ func (st *State) ApplyMoveStrict(kind PieceKind, from *Square, to Square, promot
 	// 呼び出し側の指定ミスを吸収：from == nil なら必ず drop とみなす
 	isDrop = (from == nil)
 
+	side := st.SideToMove
+
 	// 盤外チェック
-	if to.File < 1 || to.File > 9 || to.Rank < 1 || to.Rank > 9 {
-		return fmt.Errorf("out of board: to=%v", to)
+	if !st.onBoard(to) {
+		return ruleErr(CodeOutOfBoard, side, kind, from, &to)
 	}
 	if from != nil {
-		if from.File < 1 || from.File > 9 || from.Rank < 1 || from.Rank > 9 {
-			return fmt.Errorf("out of board: from=%v", *from)
+		if !st.onBoard(*from) {
+			return ruleErr(CodeOutOfBoard, side, kind, nil, from)
 		}
 	}
 
 	if isDrop {
 		// 打ちは空マス必須
 		if st.PieceAt(to) != nil {
-			return fmt.Errorf("drop to occupied square: to=%v", to)
+			return ruleErr(CodeDropOccupied, side, kind, nil, &to)
 		}
 		// 持駒が必要
 		if st.Hands[st.SideToMove][kind] <= 0 {
-			return fmt.Errorf("no piece in hand: %c", kind)
+			return ruleErr(CodeNoPieceInHand, side, kind, nil, &to)
 		}
 		// 二歩チェックはここ（歩打ちの場合のみ）
 		if kind == 'P' && st.hasPawnOnFile(st.SideToMove, to.File) {
-			return fmt.Errorf("double pawn on file: file=%d", to.File)
+			e := ruleErr(CodeDoublePawn, side, kind, nil, &to)
+			e.File = to.File
+			return e
 		}
 		// 行き所のない駒の禁止（打ち）
 		// 先手視点：歩・香は1段目、桂は1-2段目に打てない。
 		// 後手視点：歩・香は9段目、桂は8-9段目に打てない。
-		if isDrop {
-			lastRank := 1
-			secondLastRank := 2
-			if st.SideToMove == White {
-				lastRank = 9
-				secondLastRank = 8
-			}
-
-			switch kind {
-			case 'P', 'L':
-				if to.Rank == lastRank {
-					return fmt.Errorf("illegal drop (no legal moves): %c to rank=%d", kind, to.Rank)
-				}
-			case 'N':
-				if to.Rank == lastRank || to.Rank == secondLastRank {
-					return fmt.Errorf("illegal drop (no legal moves): %c to rank=%d", kind, to.Rank)
-				}
-			}
+		if st.isDeadSquare(st.SideToMove, kind, to) {
+			return ruleErr(CodeDeadPieceDrop, side, kind, nil, &to)
 		}
 		// 「打ち」で成はできない
 		if promote {
-			return fmt.Errorf("cannot promote on drop: %c", kind)
+			return ruleErr(CodeDropPromote, side, kind, nil, &to)
 		}
 	} else {
 		// 移動は from 必須
 		if from == nil {
-			return fmt.Errorf("missing from for move")
+			return ruleErr(CodeMissingFrom, side, kind, nil, &to)
 		}
 		p := st.PieceAt(*from)
 		if p == nil {
-			return fmt.Errorf("no piece at from: %v", *from)
+			return ruleErr(CodeNoPieceAtFrom, side, kind, from, &to)
+		}
+		if p.Color != side {
+			return ruleErr(CodeWrongColor, side, p.Kind, from, &to)
 		}
-		if p.Color != st.SideToMove {
-			return fmt.Errorf("piece color mismatch")
+		// 指定の駒種が移動元の駒と一致すること
+		if p.Kind != kind {
+			return ruleErr(CodeKindMismatch, side, kind, from, &to)
+		}
+		// 駒の動きとして到達できること（走り駒は途中の駒で止まる）
+		if !st.canReach(p, *from, to) {
+			return ruleErr(CodeIllegalMove, side, kind, from, &to)
 		}
 		// 自駒を取れない
 		dst := st.PieceAt(to)
 		if dst != nil && dst.Color == st.SideToMove {
-			return fmt.Errorf("cannot capture own piece: to=%v", to)
+			return ruleErr(CodeCaptureOwn, side, kind, from, &to)
 		}
-		// 成れる駒だけ成れる
+		// 成れる駒だけ成れる（成駒はさらに成れない）
 		if promote && !isPromotable(kind) {
-			return fmt.Errorf("not promotable: %c", kind)
+			return ruleErr(CodeNotPromotable, side, kind, from, &to)
+		}
+		if promote && p.Prom {
+			return ruleErr(CodeAlreadyPromoted, side, kind, from, &to)
 		}
 		// 成は敵陣に入る／出るときだけ許可（from または to が敵陣）
 		if promote {
-			if !inPromotionZone(st.SideToMove, *from) && !inPromotionZone(st.SideToMove, to) {
-				return fmt.Errorf("promotion not allowed outside zone: from=%v to=%v", *from, to)
+			if !st.inPromotionZone(st.SideToMove, *from) && !st.inPromotionZone(st.SideToMove, to) {
+				return ruleErr(CodePromotionOutsideZone, side, kind, from, &to)
 			}
 		}
-		// 不成だと行き所がなくなる駒は、成が強制
-		if !promote {
-			lastRank := 1
-			secondLastRank := 2
-			if st.SideToMove == White {
-				lastRank = 9
-				secondLastRank = 8
-			}
-
-			switch kind {
-			case 'P', 'L':
-				if to.Rank == lastRank {
-					return fmt.Errorf("must promote on last rank: %c", kind)
-				}
-			case 'N':
-				if to.Rank == lastRank || to.Rank == secondLastRank {
-					return fmt.Errorf("must promote on last ranks: %c", kind)
-				}
-			}
+		// 不成だと行き所がなくなる駒は、成が強制（成駒は対象外）
+		if !promote && !p.Prom && st.isDeadSquare(st.SideToMove, kind, to) {
+			return ruleErr(CodeMustPromote, side, kind, from, &to)
+		}
+	}
+	// 指した後に自玉が取られる手は禁止
+	mover := &Piece{Color: st.SideToMove, Kind: kind}
+	if !isDrop {
+		mover = st.PieceAt(*from)
+	}
+	if st.leavesKingInCheck(st.SideToMove, from, to, mover) {
+		return ruleErr(CodeKingInCheck, side, kind, from, &to)
+	}
+	// 打ち歩詰めの禁止
+	if isDrop && kind == 'P' && st.isPawnDropMate(to) {
+		return ruleErr(CodePawnDropMate, side, kind, nil, &to)
+	}
+	// 条件による王手義務
+	if st.Condition.mustCheck(side) {
+		moved := *mover
+		moved.Prom = moved.Prom || promote
+		if !st.givesCheck(side, from, to, &moved) {
+			return ruleErr(CodeMustCheck, side, kind, from, &to)
 		}
 	}
+
 	// 実際の更新は minimal に委譲
 	return st.ApplyMoveMinimal(kind, from, to, promote, isDrop)
 }
@@ -249,13 +295,10 @@ func isPromotable(kind PieceKind) bool {
 	}
 }
 
-func inPromotionZone(side Color, sq Square) bool {
-	// 先手：敵陣は 1〜3段目
-	// 後手：敵陣は 7〜9段目
-	if side == Black {
-		return sq.Rank >= 1 && sq.Rank <= 3
-	}
-	return sq.Rank >= 7 && sq.Rank <= 9
+// inPromotionZone は sq が side から見た敵陣かを返す。
+// 本将棋は 先手：1〜3段目、後手：7〜9段目（5五将棋は最奥の1段）。
+func (s *State) inPromotionZone(side Color, sq Square) bool {
+	return s.Rules().inZone(side, sq)
 }
 
 func (s *State) toggleSide() {
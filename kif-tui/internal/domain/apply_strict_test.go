@@ -1,6 +1,9 @@
 package domain
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 func TestApplyMoveStrict_DropToOccupiedIsError(t *testing.T) {
 	st := NewStateEmpty()
@@ -141,11 +144,11 @@ func TestApplyMoveStrict_PromoteWhenLeavingZoneIsOK(t *testing.T) {
 	st := NewStateEmpty()
 	st.SideToMove = Black
 
-	// 先手の歩：73 -> 74（from=3段目は先手の敵陣）
-	st.SetPieceAt(Square{File: 7, Rank: 3}, &Piece{Color: Black, Kind: 'P'})
+	// 先手の銀：73 -> 64（from=3段目は先手の敵陣。歩は後ろに下がれないので銀で作る）
+	st.SetPieceAt(Square{File: 7, Rank: 3}, &Piece{Color: Black, Kind: 'S'})
 	from := Square{File: 7, Rank: 3}
 
-	err := st.ApplyMoveStrict('P', &from, Square{File: 7, Rank: 4}, true, false)
+	err := st.ApplyMoveStrict('S', &from, Square{File: 6, Rank: 4}, true, false)
 	if err != nil {
 		t.Fatalf("expected nil, got %v", err)
 	}
@@ -203,7 +206,7 @@ func TestApplyMoveStrict_KnightMustPromoteOnLastTwoRanks(t *testing.T) {
 	st := NewStateEmpty()
 	st.SideToMove = Black
 
-	// 先手の桂：53 -> 41（=Rank1） / 53 -> 42（=Rank2）を作る
+	// 先手の桂：53 -> 41（=Rank1） / 54 -> 42（=Rank2）を作る
 	st.SetPieceAt(Square{File: 5, Rank: 3}, &Piece{Color: Black, Kind: 'N'})
 	from := Square{File: 5, Rank: 3}
 
@@ -220,8 +223,8 @@ func TestApplyMoveStrict_KnightMustPromoteOnLastTwoRanks(t *testing.T) {
 	// 盤面が進んでいるので、再度初期化して Rank=2 のケース
 	st = NewStateEmpty()
 	st.SideToMove = Black
-	st.SetPieceAt(Square{File: 5, Rank: 3}, &Piece{Color: Black, Kind: 'N'})
-	from = Square{File: 5, Rank: 3}
+	st.SetPieceAt(Square{File: 5, Rank: 4}, &Piece{Color: Black, Kind: 'N'})
+	from = Square{File: 5, Rank: 4}
 
 	// --- to Rank=2 (42) ---
 	err = st.ApplyMoveStrict('N', &from, Square{File: 4, Rank: 2}, false, false)
@@ -233,3 +236,69 @@ func TestApplyMoveStrict_KnightMustPromoteOnLastTwoRanks(t *testing.T) {
 		t.Fatalf("expected nil, got %v (to rank=2)", err)
 	}
 }
+
+func TestApplyMoveStrict_PawnDropMateIsError(t *testing.T) {
+	// [pawn-drop-mate]
+	// 目的：打ち歩詰めを専用エラーで拒否し、玉方に応手があれば歩打ちを許すことを確認する。
+	//
+	// 局面：後手玉 11、先手の金 32（21・22 に利き）、先手の香 19（12 の歩に紐）。
+	// 12 歩打は王手で、玉は逃げられず歩も取れない。
+	setup := func() *State {
+		st := NewStateEmpty()
+		st.SideToMove = Black
+		st.Hands[Black] = map[PieceKind]int{'P': 1}
+		st.SetPieceAt(Square{File: 1, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+		st.SetPieceAt(Square{File: 3, Rank: 2}, &Piece{Color: Black, Kind: 'G'})
+		st.SetPieceAt(Square{File: 1, Rank: 9}, &Piece{Color: Black, Kind: 'L'})
+		return st
+	}
+
+	st := setup()
+	err := st.ApplyMoveStrict('P', nil, Square{File: 1, Rank: 2}, false, true)
+	if !errors.Is(err, ErrPawnDropMate) {
+		t.Fatalf("expected ErrPawnDropMate, got %v", err)
+	}
+
+	// 香の紐が無ければ玉で歩を取れるので、打ち歩詰めではない
+	st = setup()
+	st.SetPieceAt(Square{File: 1, Rank: 9}, nil)
+	if err := st.ApplyMoveStrict('P', nil, Square{File: 1, Rank: 2}, false, true); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	// 後手の銀 21 が歩を取れるなら、打ち歩詰めではない
+	st = setup()
+	st.SetPieceAt(Square{File: 2, Rank: 1}, &Piece{Color: White, Kind: 'S'})
+	if err := st.ApplyMoveStrict('P', nil, Square{File: 1, Rank: 2}, false, true); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+}
+
+func TestApplyMoveStrict_PawnDropMateKnightSupport(t *testing.T) {
+	// [pawn-drop-mate-knight]
+	// 目的：歩の紐が桂の利き（先手の桂 24 → 12）でも打ち歩詰めになることを確認する。
+	st := NewStateEmpty()
+	st.SideToMove = Black
+	st.Hands[Black] = map[PieceKind]int{'P': 1}
+	st.SetPieceAt(Square{File: 1, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+	st.SetPieceAt(Square{File: 3, Rank: 2}, &Piece{Color: Black, Kind: 'G'})
+	st.SetPieceAt(Square{File: 2, Rank: 4}, &Piece{Color: Black, Kind: 'N'})
+
+	err := st.ApplyMoveStrict('P', nil, Square{File: 1, Rank: 2}, false, true)
+	if !errors.Is(err, ErrPawnDropMate) {
+		t.Fatalf("expected ErrPawnDropMate, got %v", err)
+	}
+}
+
+func TestApplyMoveStrict_PawnDropCheckWithEscapeIsOK(t *testing.T) {
+	// [pawn-drop-check]
+	// 目的：王手になる歩打ちでも、玉が逃げられるなら合法。
+	st := NewStateEmpty()
+	st.SideToMove = Black
+	st.Hands[Black] = map[PieceKind]int{'P': 1}
+	st.SetPieceAt(Square{File: 5, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+
+	if err := st.ApplyMoveStrict('P', nil, Square{File: 5, Rank: 2}, false, true); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+}
@@ -0,0 +1,542 @@
+package domain
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// ビットボードによる局面表現（探索・解析用）。
+// State は編集・記録向けで駒をポインタで持つため、大量の局面を辿る用途には重い。
+// Position は盤を 81 ビットの集合（色ごと・駒種ごと）と配列で持ち、持駒は固定長の配列で持つ。
+// State との行き来は Snapshot を通す（PositionFromSnapshot / Position.Snapshot）。
+// State の指し手生成（LegalMoves など）と打ち歩詰めの判定は Position で行う。
+// 敵陣・行き所のない駒は Variant のもの（variant.go）を使い、小さい盤は board のマスだけを使う。
+//
+// マス番号は (file-1)*9 + (rank-1)。11 が 0、99 が 80。
+
+// Bitboard は 81 マスのビット集合（0〜63 を Lo、64〜80 を Hi に持つ）。
+type Bitboard struct {
+	Lo, Hi uint64
+}
+
+func (b Bitboard) Has(i int) bool {
+	if i < 64 {
+		return b.Lo&(1<<uint(i)) != 0
+	}
+	return b.Hi&(1<<uint(i-64)) != 0
+}
+
+func (b *Bitboard) Set(i int) {
+	if i < 64 {
+		b.Lo |= 1 << uint(i)
+	} else {
+		b.Hi |= 1 << uint(i-64)
+	}
+}
+
+func (b *Bitboard) Clear(i int) {
+	if i < 64 {
+		b.Lo &^= 1 << uint(i)
+	} else {
+		b.Hi &^= 1 << uint(i-64)
+	}
+}
+
+func (b Bitboard) And(o Bitboard) Bitboard    { return Bitboard{b.Lo & o.Lo, b.Hi & o.Hi} }
+func (b Bitboard) Or(o Bitboard) Bitboard     { return Bitboard{b.Lo | o.Lo, b.Hi | o.Hi} }
+func (b Bitboard) AndNot(o Bitboard) Bitboard { return Bitboard{b.Lo &^ o.Lo, b.Hi &^ o.Hi} }
+func (b Bitboard) IsZero() bool               { return b.Lo == 0 && b.Hi == 0 }
+func (b Bitboard) Count() int                 { return bits.OnesCount64(b.Lo) + bits.OnesCount64(b.Hi) }
+
+// PopLSB は一番小さいマス番号を取り出して返す（空なら -1）。
+func (b *Bitboard) PopLSB() int {
+	if b.Lo != 0 {
+		i := bits.TrailingZeros64(b.Lo)
+		b.Lo &= b.Lo - 1
+		return i
+	}
+	if b.Hi != 0 {
+		i := bits.TrailingZeros64(b.Hi)
+		b.Hi &= b.Hi - 1
+		return 64 + i
+	}
+	return -1
+}
+
+func sqIndex(sq Square) int     { return (sq.File-1)*9 + (sq.Rank - 1) }
+func sqOfIndex(i int) Square    { return Square{File: i/9 + 1, Rank: i%9 + 1} }
+func rankOfIndex(i int) int     { return i%9 + 1 }
+func colorIndex(c Color) int    { return zobristColor(c) }
+func colorOfIndex(ci int) Color { return [2]Color{Black, White}[ci] }
+
+// 駒コード：kp = 駒種番号*2 + 成（zobristKinds の順）。盤のマスは 0 が空、それ以外は 1 + color*16 + kp。
+const (
+	kpPawn   = 0
+	kpLance  = 2
+	kpKnight = 4
+	kpKing   = 14
+)
+
+func pieceCode(ci, kp int) uint8 { return uint8(1 + ci*16 + kp) }
+func codeColor(c uint8) int      { return int(c-1) / 16 }
+func codeKP(c uint8) int         { return int(c-1) % 16 }
+
+var (
+	stepAttacks [2][16][81]Bitboard // [color][kp][from] 1マスだけ動ける先
+	slideDirs   [2][16][]int        // [color][kp] 走れる方向（rays の添字）
+	rays        [81][8][]int8       // [from][方向] その方向にあるマスを近い順に
+	rayDirs     = dirsKing          // rays の方向（8方向）
+)
+
+func init() {
+	for d, dd := range rayDirs {
+		for i := 0; i < 81; i++ {
+			sq := sqOfIndex(i)
+			for {
+				sq = Square{File: sq.File + dd.df, Rank: sq.Rank + dd.dr}
+				if sq.File < 1 || sq.File > 9 || sq.Rank < 1 || sq.Rank > 9 {
+					break
+				}
+				rays[i][d] = append(rays[i][d], int8(sqIndex(sq)))
+			}
+		}
+	}
+	for ci := 0; ci < 2; ci++ {
+		c := colorOfIndex(ci)
+		for kp := 0; kp < 16; kp++ {
+			kind := zobristKinds[kp/2]
+			prom := kp%2 == 1
+			if prom && !isPromotable(kind) {
+				continue
+			}
+			rule := ruleOf(kind, prom)
+			for i := 0; i < 81; i++ {
+				from := sqOfIndex(i)
+				for _, d := range rule.steps {
+					d = orient(c, d)
+					to := Square{File: from.File + d.df, Rank: from.Rank + d.dr}
+					if to.File >= 1 && to.File <= 9 && to.Rank >= 1 && to.Rank <= 9 {
+						stepAttacks[ci][kp][i].Set(sqIndex(to))
+					}
+				}
+			}
+			for _, d := range rule.slides {
+				d = orient(c, d)
+				for di, rd := range rayDirs {
+					if rd == d {
+						slideDirs[ci][kp] = append(slideDirs[ci][kp], di)
+					}
+				}
+			}
+		}
+	}
+}
+
+// Position はビットボードで表した局面。ゼロ値は使わず PositionFromSnapshot で作る。
+type Position struct {
+	rules   *Variant        // 盤・敵陣・駒の組
+	board   Bitboard        // rules の盤に含まれるマス
+	squares [81]uint8       // マスごとの駒コード（0 は空）
+	byColor [2]Bitboard     // 色ごとの駒のあるマス
+	byPiece [2][16]Bitboard // 色・駒コードごとの駒のあるマス
+	hands   [2][8]uint8     // [color][駒種番号]
+	king    [2]int8         // 玉のマス（無ければ -1）
+	side    int             // 手番（0: 先手, 1: 後手）
+}
+
+// PosMove は Position 用の指し手。打ちは From = -1。
+// Kind は zobristKinds の番号で、盤上の手では動かす駒の元の駒種。
+type PosMove struct {
+	From    int8
+	To      int8
+	Kind    uint8
+	Promote bool
+}
+
+// Move は PosMove を domain.Move に変換する。
+func (m PosMove) Move() Move {
+	mv := Move{Kind: zobristKinds[m.Kind], To: sqOfIndex(int(m.To)), Promote: m.Promote}
+	if m.From < 0 {
+		mv.IsDrop = true
+		return mv
+	}
+	from := sqOfIndex(int(m.From))
+	mv.From = &from
+	return mv
+}
+
+// PosMoveOf は domain.Move を PosMove に変換する。
+func PosMoveOf(mv Move) PosMove {
+	m := PosMove{From: -1, To: int8(sqIndex(mv.To)), Kind: uint8(zobristKind(mv.Kind)), Promote: mv.Promote}
+	if !mv.IsDrop && mv.From != nil {
+		m.From = int8(sqIndex(*mv.From))
+	}
+	return m
+}
+
+// PositionFromSnapshot は Snapshot から Position を作る（手順 Moves は引き継がない）。
+func PositionFromSnapshot(ss Snapshot) (*Position, error) {
+	p := &Position{rules: ss.Rules(), king: [2]int8{-1, -1}}
+	if ss.SideToMove == White {
+		p.side = 1
+	}
+	for f := 1; f <= 9; f++ {
+		for r := 1; r <= 9; r++ {
+			sq := Square{File: f, Rank: r}
+			if p.rules.Contains(sq) {
+				p.board.Set(sqIndex(sq))
+			}
+			pc := ss.Board[f][r]
+			if pc == nil {
+				continue
+			}
+			if !p.rules.Contains(sq) {
+				return nil, fmt.Errorf("piece outside the board at %d%d", f, r)
+			}
+			if _, ok := p.rules.PieceSet[pc.Kind]; !ok {
+				return nil, fmt.Errorf("unknown piece kind at %d%d: %c", f, r, pc.Kind)
+			}
+			if pc.Prom && !isPromotable(pc.Kind) {
+				return nil, fmt.Errorf("piece cannot be promoted at %d%d: %c", f, r, pc.Kind)
+			}
+			ci := colorIndex(pc.Color)
+			kp := zobristKind(pc.Kind) * 2
+			if pc.Prom {
+				kp++
+			}
+			i := sqIndex(Square{File: f, Rank: r})
+			if kp == kpKing {
+				if p.king[ci] >= 0 {
+					return nil, fmt.Errorf("%c has more than one king", pc.Color)
+				}
+				p.king[ci] = int8(i)
+			}
+			p.put(i, pieceCode(ci, kp))
+		}
+	}
+	for c, m := range ss.Hands {
+		for k, n := range m {
+			if n < 0 || n > 255 {
+				return nil, fmt.Errorf("bad hand count: %c %c=%d", c, k, n)
+			}
+			if k == 'K' {
+				return nil, fmt.Errorf("king cannot be in hand")
+			}
+			if _, ok := p.rules.PieceSet[k]; !ok {
+				return nil, fmt.Errorf("unknown piece kind in hand: %c", k)
+			}
+			p.hands[colorIndex(c)][zobristKind(k)] = uint8(n)
+		}
+	}
+	return p, nil
+}
+
+// Snapshot は Position を Snapshot に戻す（Moves は空）。
+func (p *Position) Snapshot() Snapshot {
+	ss := Snapshot{Hands: NewHands(), SideToMove: p.SideToMove(), Moves: make([]Move, 0)}
+	if p.rules != VariantStandard {
+		ss.Variant = p.rules
+	}
+	for i, c := range p.squares {
+		if c == 0 {
+			continue
+		}
+		sq := sqOfIndex(i)
+		kp := codeKP(c)
+		ss.Board[sq.File][sq.Rank] = &Piece{Color: colorOfIndex(codeColor(c)), Kind: zobristKinds[kp/2], Prom: kp%2 == 1}
+	}
+	for ci := 0; ci < 2; ci++ {
+		for k, n := range p.hands[ci] {
+			if n > 0 {
+				ss.Hands[colorOfIndex(ci)][zobristKinds[k]] = int(n)
+			}
+		}
+	}
+	return ss
+}
+
+// Position は State の現在の局面を Position にする。
+// 玉が2枚あるなど表現できない局面（Validate が対局を許さない局面）はエラーになる。
+func (s *State) Position() (*Position, error) {
+	// PositionFromSnapshot は読むだけなので、駒を複製せずに渡す
+	return PositionFromSnapshot(Snapshot{Board: s.Board, Hands: s.Hands, SideToMove: s.SideToMove, Variant: s.Variant})
+}
+
+// SideToMove は手番を返す。
+func (p *Position) SideToMove() Color { return colorOfIndex(p.side) }
+
+// PieceAt は sq の駒を返す（空なら nil）。
+func (p *Position) PieceAt(sq Square) *Piece {
+	c := p.squares[sqIndex(sq)]
+	if c == 0 {
+		return nil
+	}
+	kp := codeKP(c)
+	return &Piece{Color: colorOfIndex(codeColor(c)), Kind: zobristKinds[kp/2], Prom: kp%2 == 1}
+}
+
+// Hand は c の持駒 k の枚数を返す。
+func (p *Position) Hand(c Color, k PieceKind) int {
+	return int(p.hands[colorIndex(c)][zobristKind(k)])
+}
+
+func (p *Position) put(i int, c uint8) {
+	p.squares[i] = c
+	ci := codeColor(c)
+	p.byColor[ci].Set(i)
+	p.byPiece[ci][codeKP(c)].Set(i)
+}
+
+func (p *Position) remove(i int) uint8 {
+	c := p.squares[i]
+	p.squares[i] = 0
+	ci := codeColor(c)
+	p.byColor[ci].Clear(i)
+	p.byPiece[ci][codeKP(c)].Clear(i)
+	return c
+}
+
+// DoMove は m を指し、取った駒の駒コード（無ければ 0）を返す。m は疑似合法手であること。
+func (p *Position) DoMove(m PosMove) uint8 {
+	side := p.side
+	to := int(m.To)
+	if m.From < 0 {
+		p.hands[side][m.Kind]--
+		p.put(to, pieceCode(side, int(m.Kind)*2))
+		p.side ^= 1
+		return 0
+	}
+	captured := p.squares[to]
+	if captured != 0 {
+		p.remove(to)
+		p.hands[side][codeKP(captured)/2]++
+		if codeKP(captured) == kpKing {
+			p.king[side^1] = -1
+		}
+	}
+	c := p.remove(int(m.From))
+	if m.Promote {
+		c++
+	}
+	p.put(to, c)
+	if codeKP(c) == kpKing {
+		p.king[side] = m.To
+	}
+	p.side ^= 1
+	return captured
+}
+
+// UndoMove は DoMove(m) を戻す。captured は DoMove の戻り値。
+func (p *Position) UndoMove(m PosMove, captured uint8) {
+	p.side ^= 1
+	side := p.side
+	to := int(m.To)
+	if m.From < 0 {
+		p.remove(to)
+		p.hands[side][m.Kind]++
+		return
+	}
+	c := p.remove(to)
+	if m.Promote {
+		c--
+	}
+	p.put(int(m.From), c)
+	if codeKP(c) == kpKing {
+		p.king[side] = m.From
+	}
+	if captured != 0 {
+		p.put(to, captured)
+		p.hands[side][codeKP(captured)/2]--
+		if codeKP(captured) == kpKing {
+			p.king[side^1] = m.To
+		}
+	}
+}
+
+// attacked は、マス i に ci 側の駒の利きがあるかを返す。
+func (p *Position) attacked(i int, ci int) bool {
+	// 1マスの利き：i から相手向きに動いた先に、その駒がいれば利いている
+	for kp := 0; kp < 16; kp++ {
+		pcs := p.byPiece[ci][kp]
+		if pcs.IsZero() {
+			continue
+		}
+		if !stepAttacks[ci^1][kp][i].And(pcs).IsZero() {
+			return true
+		}
+	}
+	// 走りの利き：各方向で最初に当たった駒が、逆向きに走れる駒か
+	for d := range rayDirs {
+		for _, j := range rays[i][d] {
+			c := p.squares[j]
+			if c == 0 {
+				continue
+			}
+			if codeColor(c) == ci {
+				for _, sd := range slideDirs[ci][codeKP(c)] {
+					if rayDirs[sd].df == -rayDirs[d].df && rayDirs[sd].dr == -rayDirs[d].dr {
+						return true
+					}
+				}
+			}
+			break
+		}
+	}
+	return false
+}
+
+// InCheck は手番側の玉に王手がかかっているかを返す。
+func (p *Position) InCheck() bool {
+	k := p.king[p.side]
+	return k >= 0 && p.attacked(int(k), p.side^1)
+}
+
+// inZone は i が ci 側から見た敵陣かを返す（Variant の敵陣）。
+func (p *Position) inZone(ci, i int) bool {
+	return p.rules.inZone(colorOfIndex(ci), sqOfIndex(i))
+}
+
+// deadSquare は、不成の kp を i に置くと行き所がなくなるかを返す（Variant の判定）。
+func (p *Position) deadSquare(ci, kp, i int) bool {
+	return p.rules.isDeadSquare(colorOfIndex(ci), zobristKinds[kp/2], sqOfIndex(i))
+}
+
+func (p *Position) appendChoices(out []PosMove, ci, kp, from, to int) []PosMove {
+	kind := uint8(kp / 2)
+	if kp%2 == 0 && isPromotable(zobristKinds[kind]) && (p.inZone(ci, from) || p.inZone(ci, to)) {
+		out = append(out, PosMove{From: int8(from), To: int8(to), Kind: kind, Promote: true})
+	}
+	if kp%2 == 1 || !p.deadSquare(ci, kp, to) {
+		out = append(out, PosMove{From: int8(from), To: int8(to), Kind: kind})
+	}
+	return out
+}
+
+// PseudoLegalMoves は手番側の疑似合法手を buf に追加して返す（並びは State と同じとは限らない）。
+func (p *Position) PseudoLegalMoves(buf []PosMove) []PosMove {
+	ci := p.side
+	own := p.byColor[ci]
+	pcs := own
+	for from := pcs.PopLSB(); from >= 0; from = pcs.PopLSB() {
+		kp := codeKP(p.squares[from])
+		tos := stepAttacks[ci][kp][from].And(p.board).AndNot(own)
+		for to := tos.PopLSB(); to >= 0; to = tos.PopLSB() {
+			buf = p.appendChoices(buf, ci, kp, from, to)
+		}
+		for _, d := range slideDirs[ci][kp] {
+			for _, j := range rays[from][d] {
+				c := p.squares[j]
+				if !p.board.Has(int(j)) || (c != 0 && codeColor(c) == ci) {
+					break
+				}
+				buf = p.appendChoices(buf, ci, kp, from, int(j))
+				if c != 0 {
+					break
+				}
+			}
+		}
+	}
+
+	var pawnFiles [10]bool
+	pawns := p.byPiece[ci][kpPawn]
+	for i := pawns.PopLSB(); i >= 0; i = pawns.PopLSB() {
+		pawnFiles[i/9+1] = true
+	}
+	for _, k := range handOrder {
+		kind := zobristKind(k)
+		if p.hands[ci][kind] == 0 {
+			continue
+		}
+		for to := 0; to < 81; to++ {
+			if !p.board.Has(to) || p.squares[to] != 0 || p.deadSquare(ci, kind*2, to) {
+				continue
+			}
+			if kind*2 == kpPawn && pawnFiles[to/9+1] {
+				continue
+			}
+			buf = append(buf, PosMove{From: -1, To: int8(to), Kind: uint8(kind)})
+		}
+	}
+	return buf
+}
+
+// LegalMoves は手番側の合法手を buf に追加して返す。
+func (p *Position) LegalMoves(buf []PosMove) []PosMove {
+	start := len(buf)
+	buf = p.PseudoLegalMoves(buf)
+	out := buf[:start]
+	for _, m := range buf[start:] {
+		if p.isLegalPseudo(m) {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// HasLegalMove は手番側に合法手が1つでもあるかを返す。
+func (p *Position) HasLegalMove() bool {
+	var buf [600]PosMove
+	for _, m := range p.PseudoLegalMoves(buf[:0]) {
+		if p.isLegalPseudo(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// isLegalPseudo は疑似合法手 m が王手放置・打ち歩詰めでないかを返す。
+func (p *Position) isLegalPseudo(m PosMove) bool {
+	ci := p.side
+	captured := p.DoMove(m)
+	legal := p.king[ci] < 0 || !p.attacked(int(p.king[ci]), ci^1)
+	if legal && m.From < 0 && int(m.Kind)*2 == kpPawn && p.matedByPawn() {
+		legal = false
+	}
+	p.UndoMove(m, captured)
+	return legal
+}
+
+// matedByPawn は、歩を打った直後の局面で手番側（打たれた側）が詰んでいるか（打ち歩詰め）を返す。
+// 打ち歩詰めの判定はここだけで行う（State.ApplyMoveStrict も isPawnDropMate 経由でこれを使う）。
+func (p *Position) matedByPawn() bool {
+	return p.InCheck() && !p.hasLegalBoardMove()
+}
+
+// hasLegalBoardMove は手番側に盤上の合法手（打ちを除く）が1つでもあるかを返す。
+// 歩の王手には合駒できないので、打ち歩詰めの判定にはこれで足りる。
+func (p *Position) hasLegalBoardMove() bool {
+	var buf [256]PosMove
+	for _, m := range p.PseudoLegalMoves(buf[:0]) {
+		if m.From >= 0 && p.isLegalPseudo(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// Perft は depth 手先までの合法手順の数を返す。
+func (p *Position) Perft(depth int) uint64 {
+	bufs := make([][]PosMove, depth+1)
+	for i := range bufs {
+		bufs[i] = make([]PosMove, 0, 600)
+	}
+	return p.perft(depth, bufs)
+}
+
+func (p *Position) perft(depth int, bufs [][]PosMove) uint64 {
+	if depth <= 0 {
+		return 1
+	}
+	moves := p.LegalMoves(bufs[depth][:0])
+	bufs[depth] = moves
+	if depth == 1 {
+		return uint64(len(moves))
+	}
+	var n uint64
+	for _, m := range moves {
+		captured := p.DoMove(m)
+		n += p.perft(depth-1, bufs)
+		p.UndoMove(m, captured)
+	}
+	return n
+}
@@ -0,0 +1,136 @@
+package domain
+
+import "testing"
+
+func TestPosition_SnapshotRoundTrip(t *testing.T) {
+	// [bitboard-roundtrip]
+	// 目的：Snapshot → Position → Snapshot で盤・持駒・手番が変わらないこと。
+	st := NewStateHirate()
+	st.SetPieceAt(Square{File: 5, Rank: 5}, &Piece{Color: White, Kind: 'R', Prom: true})
+	st.Hands[Black]['G'] = 1
+	st.Hands[White]['P'] = 3
+	st.SideToMove = White
+
+	p, err := st.Position()
+	if err != nil {
+		t.Fatalf("Position: %v", err)
+	}
+	back := NewStateEmpty()
+	back.RestoreSnapshot(p.Snapshot())
+	if !samePosition(st, back) {
+		t.Fatalf("round trip changed the position")
+	}
+	if p.SideToMove() != White || p.Hand(White, 'P') != 3 || p.PieceAt(Square{File: 5, Rank: 5}).Prom != true {
+		t.Fatalf("accessors disagree with the snapshot")
+	}
+}
+
+func TestPosition_RejectsBadSnapshot(t *testing.T) {
+	// [bitboard-reject]
+	// 目的：表現できない局面（玉2枚・成れない駒の成）はエラーになること。
+	st := NewStateEmpty()
+	st.SetPieceAt(Square{File: 5, Rank: 9}, &Piece{Color: Black, Kind: 'K'})
+	st.SetPieceAt(Square{File: 4, Rank: 9}, &Piece{Color: Black, Kind: 'K'})
+	if _, err := st.Position(); err == nil {
+		t.Fatalf("expected error for two kings")
+	}
+	st = NewStateEmpty()
+	st.SetPieceAt(Square{File: 5, Rank: 5}, &Piece{Color: Black, Kind: 'G', Prom: true})
+	if _, err := st.Position(); err == nil {
+		t.Fatalf("expected error for a promoted gold")
+	}
+}
+
+func TestPosition_PerftKnownValues(t *testing.T) {
+	// [bitboard-perft]
+	// 目的：Position の perft が既知の値（平手・5五将棋・打ち歩詰めの局面）と一致すること。
+	want := []uint64{1, 30, 900, 25470}
+	if !testing.Short() {
+		want = append(want, 719731)
+	}
+	p, err := NewStateHirate().Position()
+	if err != nil {
+		t.Fatalf("Position: %v", err)
+	}
+	for depth, n := range want {
+		if got := p.Perft(depth); got != n {
+			t.Fatalf("hirate perft(%d)=%d want %d", depth, got, n)
+		}
+	}
+
+	// 5五将棋（既知の値は variant_test.go と同じ）
+	p, err = NewStateMinishogi().Position()
+	if err != nil {
+		t.Fatalf("Position: %v", err)
+	}
+	for depth, n := range []uint64{1, 14, 181, 2512} {
+		if got := p.Perft(depth); got != n {
+			t.Fatalf("minishogi perft(%d)=%d want %d", depth, got, n)
+		}
+	}
+
+	// 打ち歩詰めの局面（State 側の参照値は movegen_test.go）
+	st := NewStateEmpty()
+	st.SetPieceAt(Square{File: 1, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+	st.SetPieceAt(Square{File: 3, Rank: 2}, &Piece{Color: Black, Kind: 'G'})
+	st.SetPieceAt(Square{File: 2, Rank: 4}, &Piece{Color: Black, Kind: 'N'})
+	st.Hands[Black]['P'] = 1
+	p, err = st.Position()
+	if err != nil {
+		t.Fatalf("Position: %v", err)
+	}
+	for depth, n := range []uint64{1, 76, 9, 680} {
+		if got := p.Perft(depth); got != n {
+			t.Fatalf("pawn-drop-mate perft(%d)=%d want %d", depth, got, n)
+		}
+	}
+}
+
+func TestPosition_LegalMovesMatchStrict(t *testing.T) {
+	// [bitboard-legal]
+	// 目的：平手と5五将棋で対局を進めながら、各局面の合法手の集合が
+	// ApplyMoveStrict（駒の利き・王手放置・打ち歩詰めの検査）で通る手の集合と一致すること。
+	plies := 60
+	if testing.Short() {
+		plies = 20
+	}
+	for _, start := range []*State{NewStateHirate(), NewStateMinishogi()} {
+		st := start
+		for ply := 0; ply < plies; ply++ {
+			moves := st.LegalMoves()
+			got := map[PosMove]bool{}
+			for _, mv := range moves {
+				got[PosMoveOf(mv)] = true
+			}
+			want := strictMoves(st)
+			if len(got) != len(want) {
+				t.Fatalf("%s ply %d: %d legal moves, want %d", st.Rules().Name, ply, len(got), len(want))
+			}
+			for m := range want {
+				if !got[m] {
+					t.Fatalf("%s ply %d: missing move %+v", st.Rules().Name, ply, m.Move())
+				}
+			}
+			if len(moves) == 0 {
+				break
+			}
+			mv := moves[(ply*7+3)%len(moves)]
+			if err := st.ApplyMoveMinimal(mv.Kind, mv.From, mv.To, mv.Promote, mv.IsDrop); err != nil {
+				t.Fatalf("ply %d: %v", ply, err)
+			}
+		}
+	}
+}
+
+func BenchmarkPerft3_State(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		NewStateHirate().Perft(3)
+	}
+}
+
+func BenchmarkPerft3_Position(b *testing.B) {
+	p, _ := NewStateHirate().Position()
+	for i := 0; i < b.N; i++ {
+		p.Perft(3)
+	}
+}
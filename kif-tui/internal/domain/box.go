@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 駒箱（盤上にも持駒にも無い駒）。
+// 駒箱は PieceSet から盤上と両者の持駒を引いて求める。EDIT の配置・持駒編集は
+// PlaceFromBox / SetHandCounts を通すことで、一組の枚数を超えないようにする。
+
+// ErrBoxEmpty は、駒箱に必要な駒が残っていないことを表す。
+var ErrBoxEmpty = errors.New("no such piece left in the box")
+
+// Box は駒箱の中身（駒種→枚数、成駒は元の駒として数える）を返す。
+func (s *State) Box() map[PieceKind]int {
+	s.ensureHands()
+	box := map[PieceKind]int{}
+	for k, n := range s.Rules().PieceSet {
+		box[k] = n
+	}
+	for f := 1; f <= 9; f++ {
+		for r := 1; r <= 9; r++ {
+			if p := s.Board[f][r]; p != nil {
+				box[p.Kind]--
+			}
+		}
+	}
+	for _, m := range s.Hands {
+		for k, n := range m {
+			box[k] -= n
+		}
+	}
+	for k, n := range box {
+		if n < 0 {
+			// 駒箱を通さずに置かれた局面（超過は Validate で報告する）
+			box[k] = 0
+		}
+	}
+	return box
+}
+
+// PlaceFromBox は、駒箱から p を取り出して sq に置く。
+// sq にあった駒は駒箱に戻してから数える。
+func (s *State) PlaceFromBox(sq Square, p Piece) error {
+	if !s.onBoard(sq) {
+		return fmt.Errorf("out of board: %v", sq)
+	}
+	if _, ok := s.Rules().PieceSet[p.Kind]; !ok {
+		return fmt.Errorf("unknown piece kind: %c", p.Kind)
+	}
+	avail := s.Box()[p.Kind]
+	if old := s.PieceAt(sq); old != nil && old.Kind == p.Kind {
+		avail++
+	}
+	if avail <= 0 {
+		return fmt.Errorf("%w: %c", ErrBoxEmpty, p.Kind)
+	}
+	s.SetPieceAt(sq, &p)
+	return nil
+}
+
+// SetHandCounts は、駒種 k の持駒を先手 black 枚・後手 white 枚にする。
+// 増えた分は駒箱から取り出し、減った分は駒箱に戻す。
+func (s *State) SetHandCounts(k PieceKind, black, white int) error {
+	s.ensureHands()
+	if k == 'K' {
+		return fmt.Errorf("king cannot be in hand")
+	}
+	if _, ok := s.Rules().PieceSet[k]; !ok {
+		return fmt.Errorf("unknown piece kind: %c", k)
+	}
+	if black < 0 || white < 0 {
+		return fmt.Errorf("counts must be >= 0")
+	}
+	avail := s.Box()[k] + s.Hands[Black][k] + s.Hands[White][k]
+	if black+white > avail {
+		return fmt.Errorf("%w: %c (max %d in hands)", ErrBoxEmpty, k, avail)
+	}
+	setHand := func(c Color, n int) {
+		if n == 0 {
+			delete(s.Hands[c], k)
+			return
+		}
+		s.Hands[c][k] = n
+	}
+	setHand(Black, black)
+	setHand(White, white)
+	return nil
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBox_Hirate(t *testing.T) {
+	// [box-hirate]
+	// 目的：平手の初期局面では駒箱が空であること。
+	for k, n := range NewStateHirate().Box() {
+		if n != 0 {
+			t.Fatalf("box[%c]=%d want 0", k, n)
+		}
+	}
+}
+
+func TestPlaceFromBox(t *testing.T) {
+	// [box-place]
+	// 目的：駒箱に無い駒は置けず、置き換えた駒は駒箱に戻ること。
+	st := NewStateEmpty()
+	if err := st.PlaceFromBox(Square{File: 1, Rank: 1}, Piece{Color: Black, Kind: 'R'}); err != nil {
+		t.Fatal(err)
+	}
+	// 竜も飛として数える
+	if err := st.PlaceFromBox(Square{File: 2, Rank: 2}, Piece{Color: White, Kind: 'R', Prom: true}); err != nil {
+		t.Fatal(err)
+	}
+	err := st.PlaceFromBox(Square{File: 3, Rank: 3}, Piece{Color: Black, Kind: 'R'})
+	if !errors.Is(err, ErrBoxEmpty) {
+		t.Fatalf("expected ErrBoxEmpty, got %v", err)
+	}
+
+	// 同じマスの飛を置き換えるのは可（元の飛は駒箱に戻る）
+	if err := st.PlaceFromBox(Square{File: 1, Rank: 1}, Piece{Color: White, Kind: 'R'}); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+
+	// 盤上から取り除けば、また置ける
+	st.SetPieceAt(Square{File: 2, Rank: 2}, nil)
+	if got := st.Box()['R']; got != 1 {
+		t.Fatalf("box[R]=%d want 1", got)
+	}
+	if err := st.PlaceFromBox(Square{File: 3, Rank: 3}, Piece{Color: Black, Kind: 'R'}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetHandCounts(t *testing.T) {
+	// [box-hands]
+	// 目的：持駒の編集は駒種ごとの枚数（盤上・相手の持駒を含む）を超えられないこと。
+	st := NewStateEmpty()
+	st.SetPieceAt(Square{File: 5, Rank: 5}, &Piece{Color: Black, Kind: 'G'})
+
+	if err := st.SetHandCounts('G', 2, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetHandCounts('G', 2, 2); !errors.Is(err, ErrBoxEmpty) {
+		t.Fatalf("expected ErrBoxEmpty, got %v", err)
+	}
+	// 先手→後手へ付け替えるのは可
+	if err := st.SetHandCounts('G', 0, 3); err != nil {
+		t.Fatal(err)
+	}
+	if st.Hands[Black]['G'] != 0 || st.Hands[White]['G'] != 3 || st.Box()['G'] != 0 {
+		t.Fatalf("unexpected hands/box: %v box=%d", st.Hands, st.Box()['G'])
+	}
+
+	if err := st.SetHandCounts('P', 19, 0); !errors.Is(err, ErrBoxEmpty) {
+		t.Fatalf("expected ErrBoxEmpty for 19 pawns, got %v", err)
+	}
+	if err := st.SetHandCounts('K', 1, 0); err == nil {
+		t.Fatalf("expected error for king in hand")
+	}
+	if err := st.SetHandCounts('P', -1, 0); err == nil {
+		t.Fatalf("expected error for negative count")
+	}
+}
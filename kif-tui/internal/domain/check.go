@@ -0,0 +1,94 @@
+package domain
+
+// 利き（attack）と王手の判定。
+
+// Opponent は相手側の色を返す。
+func (c Color) Opponent() Color {
+	if c == Black {
+		return White
+	}
+	return Black
+}
+
+// KingSquare は c 側の玉の位置を返す。盤上に玉が無ければ ok=false。
+func (s *State) KingSquare(c Color) (sq Square, ok bool) {
+	for f := 1; f <= 9; f++ {
+		for r := 1; r <= 9; r++ {
+			p := s.Board[f][r]
+			if p != nil && p.Color == c && p.Kind == 'K' {
+				return Square{File: f, Rank: r}, true
+			}
+		}
+	}
+	return Square{}, false
+}
+
+// IsAttacked は、by 側の駒が sq に利いているかを返す。
+// sq から8方向に最初に当たる駒と、桂の位置だけを調べる。
+func (s *State) IsAttacked(sq Square, by Color) bool {
+	for _, d := range dirsKing {
+		cur := Square{File: sq.File + d.df, Rank: sq.Rank + d.dr}
+		for s.onBoard(cur) {
+			p := s.PieceAt(cur)
+			if p == nil {
+				cur = Square{File: cur.File + d.df, Rank: cur.Rank + d.dr}
+				continue
+			}
+			if p.Color == by && s.canReach(p, cur, sq) {
+				return true
+			}
+			break
+		}
+	}
+	for _, d := range dirsKnight {
+		// by 側の桂は sq から見て「by の後ろ向き」2段の位置にいる
+		d = orient(by, d)
+		from := Square{File: sq.File - d.df, Rank: sq.Rank - d.dr}
+		p := s.PieceAt(from)
+		if p != nil && p.Color == by && p.Kind == 'N' && !p.Prom {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInCheck は c 側の玉に王手がかかっているかを返す（玉が無ければ false）。
+func (s *State) IsInCheck(c Color) bool {
+	ksq, ok := s.KingSquare(c)
+	if !ok {
+		return false
+	}
+	return s.IsAttacked(ksq, c.Opponent())
+}
+
+// leavesKingInCheck は、side が p を from→to に動かした（from==nil なら打った）局面で
+// 自玉に王手がかかっているかを返す。盤面は一時的に書き換えて元に戻す。
+// 持駒は利きに関係しないので触らない。
+func (s *State) leavesKingInCheck(side Color, from *Square, to Square, p *Piece) bool {
+	savedTo := s.Board[to.File][to.Rank]
+	var savedFrom *Piece
+	if from != nil {
+		savedFrom = s.Board[from.File][from.Rank]
+		s.Board[from.File][from.Rank] = nil
+	}
+	s.Board[to.File][to.Rank] = p
+
+	inCheck := s.IsInCheck(side)
+
+	s.Board[to.File][to.Rank] = savedTo
+	if from != nil {
+		s.Board[from.File][from.Rank] = savedFrom
+	}
+	return inCheck
+}
+
+// isPawnDropMate は、手番側が to に歩を打つと相手玉が詰む（打ち歩詰め）かを返す。
+// 判定は Position.matedByPawn で行い、指し手生成と同じ規則を使う。
+func (s *State) isPawnDropMate(to Square) bool {
+	p, err := s.Position()
+	if err != nil {
+		return false
+	}
+	p.DoMove(PosMove{From: -1, To: int8(sqIndex(to)), Kind: uint8(zobristKind('P'))})
+	return p.matedByPawn()
+}
@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIsInCheck(t *testing.T) {
+	// [is-in-check]
+	// 目的：走り駒・桂・成駒の利きで王手を判定し、遮りがあれば王手でないことを確認する。
+	tests := []struct {
+		name   string
+		pieces map[Square]Piece
+		want   bool
+	}{
+		{
+			name: "rook on file",
+			pieces: map[Square]Piece{
+				{File: 5, Rank: 1}: {Color: White, Kind: 'K'},
+				{File: 5, Rank: 8}: {Color: Black, Kind: 'R'},
+			},
+			want: true,
+		},
+		{
+			name: "rook blocked",
+			pieces: map[Square]Piece{
+				{File: 5, Rank: 1}: {Color: White, Kind: 'K'},
+				{File: 5, Rank: 4}: {Color: White, Kind: 'G'},
+				{File: 5, Rank: 8}: {Color: Black, Kind: 'R'},
+			},
+			want: false,
+		},
+		{
+			name: "knight",
+			pieces: map[Square]Piece{
+				{File: 5, Rank: 1}: {Color: White, Kind: 'K'},
+				{File: 4, Rank: 3}: {Color: Black, Kind: 'N'},
+			},
+			want: true,
+		},
+		{
+			name: "promoted knight does not jump",
+			pieces: map[Square]Piece{
+				{File: 5, Rank: 1}: {Color: White, Kind: 'K'},
+				{File: 4, Rank: 3}: {Color: Black, Kind: 'N', Prom: true},
+			},
+			want: false,
+		},
+		{
+			name: "pawn backward is not check",
+			pieces: map[Square]Piece{
+				{File: 5, Rank: 5}: {Color: White, Kind: 'K'},
+				{File: 5, Rank: 4}: {Color: Black, Kind: 'P'},
+			},
+			want: false,
+		},
+		{
+			name: "horse step",
+			pieces: map[Square]Piece{
+				{File: 5, Rank: 1}: {Color: White, Kind: 'K'},
+				{File: 5, Rank: 2}: {Color: Black, Kind: 'B', Prom: true},
+			},
+			want: true,
+		},
+		{
+			name: "own piece does not check",
+			pieces: map[Square]Piece{
+				{File: 5, Rank: 1}: {Color: White, Kind: 'K'},
+				{File: 5, Rank: 5}: {Color: White, Kind: 'R'},
+			},
+			want: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			st := NewStateEmpty()
+			for sq, p := range tc.pieces {
+				p := p
+				st.SetPieceAt(sq, &p)
+			}
+			if got := st.IsInCheck(White); got != tc.want {
+				t.Fatalf("IsInCheck(White)=%v want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestApplyMoveStrict_PinnedPieceCannotMove(t *testing.T) {
+	// [pinned-piece]
+	// 目的：飛車にピンされた金を筋から外す手は、自玉が取られるので禁止。
+	st := NewStateEmpty()
+	st.SideToMove = White
+	st.SetPieceAt(Square{File: 5, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+	st.SetPieceAt(Square{File: 5, Rank: 2}, &Piece{Color: White, Kind: 'G'})
+	st.SetPieceAt(Square{File: 5, Rank: 9}, &Piece{Color: Black, Kind: 'R'})
+
+	from := Square{File: 5, Rank: 2}
+	err := st.ApplyMoveStrict('G', &from, Square{File: 4, Rank: 2}, false, false)
+	if !errors.Is(err, ErrKingInCheck) {
+		t.Fatalf("expected ErrKingInCheck, got %v", err)
+	}
+
+	// 筋に沿った移動なら可
+	if err := st.ApplyMoveStrict('G', &from, Square{File: 5, Rank: 3}, false, false); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+}
+
+func TestApplyMoveStrict_KingIntoAttackedSquareIsError(t *testing.T) {
+	// [king-into-check]
+	// 目的：相手の利きがあるマスに玉を動かせない。
+	st := NewStateEmpty()
+	st.SideToMove = White
+	st.SetPieceAt(Square{File: 5, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+	st.SetPieceAt(Square{File: 4, Rank: 9}, &Piece{Color: Black, Kind: 'R'})
+
+	from := Square{File: 5, Rank: 1}
+	err := st.ApplyMoveStrict('K', &from, Square{File: 4, Rank: 1}, false, false)
+	if !errors.Is(err, ErrKingInCheck) {
+		t.Fatalf("expected ErrKingInCheck, got %v", err)
+	}
+}
+
+func TestApplyMoveStrict_DropMustResolveCheck(t *testing.T) {
+	// [drop-resolve-check]
+	// 目的：王手を放置する打ちは禁止、合駒になる打ちは可。
+	st := NewStateEmpty()
+	st.SideToMove = White
+	st.Hands[White] = map[PieceKind]int{'G': 1}
+	st.SetPieceAt(Square{File: 5, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+	st.SetPieceAt(Square{File: 5, Rank: 9}, &Piece{Color: Black, Kind: 'L'})
+
+	err := st.ApplyMoveStrict('G', nil, Square{File: 1, Rank: 1}, false, true)
+	if !errors.Is(err, ErrKingInCheck) {
+		t.Fatalf("expected ErrKingInCheck, got %v", err)
+	}
+	if err := st.ApplyMoveStrict('G', nil, Square{File: 5, Rank: 5}, false, true); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+}
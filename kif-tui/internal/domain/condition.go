@@ -0,0 +1,55 @@
+package domain
+
+// フェアリー詰将棋の条件（協力詰・自玉詰・ばか自殺詰）。
+// 条件は局面の属性として State/Snapshot に持たせ、指し手の検査と終局判定だけを変える。
+// 攻方はいつも先手、玉方は後手とする（詰将棋と同じ）。
+
+// Condition は作品の条件。ConditionNone は通常の詰将棋・対局。
+type Condition int
+
+const (
+	ConditionNone         Condition = iota
+	ConditionHelpmate               // 協力詰：先後協力して後手玉を詰める（先手は毎手王手）
+	ConditionSelfmate               // 自玉詰：先手が後手に先手玉を詰めさせる（先手の王手義務なし）
+	ConditionHelpSelfmate           // ばか自殺詰：先後協力して後手に先手玉を詰めさせる（先手は毎手王手）
+)
+
+// Conditions は選べる条件の一覧（ConditionNone を除く）。
+var Conditions = []Condition{ConditionHelpmate, ConditionSelfmate, ConditionHelpSelfmate}
+
+var conditionNames = map[Condition]string{
+	ConditionHelpmate:     "協力詰",
+	ConditionSelfmate:     "自玉詰",
+	ConditionHelpSelfmate: "ばか自殺詰",
+}
+
+// Name は KIF の「条件：」と終了行に書く名前を返す（ConditionNone は空）。
+func (c Condition) Name() string { return conditionNames[c] }
+
+// ConditionByName は名前（協力詰 など）から条件を返す。
+func ConditionByName(name string) (Condition, bool) {
+	for _, c := range Conditions {
+		if c.Name() == name {
+			return c, true
+		}
+	}
+	return ConditionNone, false
+}
+
+// Goal は、この条件で詰まされる側を返す（協力詰は後手、自玉詰・ばか自殺詰は先手）。
+func (c Condition) Goal() Color {
+	if c == ConditionSelfmate || c == ConditionHelpSelfmate {
+		return Black
+	}
+	return White
+}
+
+// mustCheck は side の手が王手でなければならないかを返す。
+func (c Condition) mustCheck(side Color) bool {
+	return side == Black && (c == ConditionHelpmate || c == ConditionHelpSelfmate)
+}
+
+// givesCheck は、mover を from から to へ動かす（from==nil なら打つ）と相手玉に王手がかかるかを返す。
+func (s *State) givesCheck(side Color, from *Square, to Square, mover *Piece) bool {
+	return s.leavesKingInCheck(side.Opponent(), from, to, mover)
+}
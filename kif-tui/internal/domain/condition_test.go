@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCondition_CheckObligation(t *testing.T) {
+	// [condition-check]
+	// 目的：協力詰・ばか自殺詰では先手の王手でない手が ErrMustCheck になり、自玉詰では指せること。
+	// 後手の手には王手義務がないこと。
+	for _, tc := range []struct {
+		cond    Condition
+		wantErr bool
+	}{
+		{ConditionNone, false},
+		{ConditionHelpmate, true},
+		{ConditionSelfmate, false},
+		{ConditionHelpSelfmate, true},
+	} {
+		st := NewStateEmpty()
+		st.Condition = tc.cond
+		st.SetPieceAt(Square{File: 5, Rank: 9}, &Piece{Color: Black, Kind: 'K'})
+		st.SetPieceAt(Square{File: 5, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+		st.Hands[Black]['G'] = 1
+
+		// ８八金打（王手でない）
+		err := st.ApplyMoveStrict('G', nil, Square{File: 8, Rank: 8}, false, true)
+		if got := errors.Is(err, ErrMustCheck); got != tc.wantErr {
+			t.Fatalf("%s: err=%v, want ErrMustCheck=%v", tc.cond.Name(), err, tc.wantErr)
+		}
+		if tc.wantErr {
+			// ５二金打（王手）なら指せる
+			if err := st.ApplyMoveStrict('G', nil, Square{File: 5, Rank: 2}, false, true); err != nil {
+				t.Fatalf("%s: checking drop rejected: %v", tc.cond.Name(), err)
+			}
+		}
+		// 後手の王手でない手
+		if err := st.ApplyMoveStrict('K', &Square{File: 5, Rank: 1}, Square{File: 4, Rank: 1}, false, false); err != nil &&
+			!errors.Is(err, ErrKingInCheck) {
+			t.Fatalf("%s: white move rejected: %v", tc.cond.Name(), err)
+		}
+	}
+}
+
+func TestCondition_Ending(t *testing.T) {
+	// [condition-ending]
+	// 目的：条件の側（協力詰は後手、自玉詰は先手）が詰めば EndingConditionMate、逆の側なら通常の詰みになること。
+	mateWhite := func(c Condition) *State {
+		// 後手玉 51、先手 53歩・持駒 金 → ５二金打で詰み
+		st := NewStateEmpty()
+		st.Condition = c
+		st.SetPieceAt(Square{File: 5, Rank: 9}, &Piece{Color: Black, Kind: 'K'})
+		st.SetPieceAt(Square{File: 5, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+		st.SetPieceAt(Square{File: 5, Rank: 3}, &Piece{Color: Black, Kind: 'P'})
+		st.Hands[Black]['G'] = 1
+		if err := st.ApplyMoveStrict('G', nil, Square{File: 5, Rank: 2}, false, true); err != nil {
+			t.Fatalf("drop: %v", err)
+		}
+		return st
+	}
+	mateBlack := func(c Condition) *State {
+		// 先手玉 11、後手 91飛・23銀・持駒 金 → １二金打で詰み
+		st := NewStateEmpty()
+		st.Condition = c
+		st.SideToMove = White
+		st.SetPieceAt(Square{File: 1, Rank: 1}, &Piece{Color: Black, Kind: 'K'})
+		st.SetPieceAt(Square{File: 5, Rank: 9}, &Piece{Color: White, Kind: 'K'})
+		st.SetPieceAt(Square{File: 9, Rank: 1}, &Piece{Color: White, Kind: 'R'})
+		st.SetPieceAt(Square{File: 2, Rank: 3}, &Piece{Color: White, Kind: 'S'})
+		st.Hands[White]['G'] = 1
+		if err := st.ApplyMoveStrict('G', nil, Square{File: 1, Rank: 2}, false, true); err != nil {
+			t.Fatalf("drop: %v", err)
+		}
+		return st
+	}
+
+	tests := []struct {
+		name string
+		st   *State
+		want Ending
+	}{
+		{"none/white-mated", mateWhite(ConditionNone), EndingCheckmate},
+		{"helpmate/white-mated", mateWhite(ConditionHelpmate), EndingConditionMate},
+		{"selfmate/white-mated", mateWhite(ConditionSelfmate), EndingCheckmate},
+		{"selfmate/black-mated", mateBlack(ConditionSelfmate), EndingConditionMate},
+		{"helpselfmate/black-mated", mateBlack(ConditionHelpSelfmate), EndingConditionMate},
+		{"helpmate/black-mated", mateBlack(ConditionHelpmate), EndingCheckmate},
+	}
+	for _, tc := range tests {
+		if got := tc.st.DetectEnding(); got != tc.want {
+			t.Fatalf("%s: DetectEnding=%v want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCondition_ByName(t *testing.T) {
+	for _, c := range Conditions {
+		if got, ok := ConditionByName(c.Name()); !ok || got != c {
+			t.Fatalf("ConditionByName(%s) = %v,%v", c.Name(), got, ok)
+		}
+	}
+	if _, ok := ConditionByName("詰将棋"); ok {
+		t.Fatalf("ConditionByName accepted an unknown name")
+	}
+}
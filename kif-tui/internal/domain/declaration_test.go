@@ -0,0 +1,79 @@
+package domain
+
+import "testing"
+
+func TestEvaluateDeclaration(t *testing.T) {
+	// [declaration]
+	// 目的：入玉宣言の条件（玉の位置・敵陣の枚数・王手）と、27点法／24点法の点数判定を確認する。
+	//
+	// 局面：先手玉 52、敵陣に 飛・角・金4・銀4（10枚・18点）、持駒は歩のみ。
+	setup := func(pawns int) *State {
+		st := NewStateEmpty()
+		st.SideToMove = Black
+		st.SetPieceAt(Square{File: 5, Rank: 2}, &Piece{Color: Black, Kind: 'K'})
+		st.SetPieceAt(Square{File: 5, Rank: 9}, &Piece{Color: White, Kind: 'K'})
+		st.SetPieceAt(Square{File: 1, Rank: 1}, &Piece{Color: Black, Kind: 'R'})
+		st.SetPieceAt(Square{File: 9, Rank: 1}, &Piece{Color: Black, Kind: 'B'})
+		for _, f := range []int{3, 4, 6, 7} {
+			st.SetPieceAt(Square{File: f, Rank: 1}, &Piece{Color: Black, Kind: 'G'})
+			st.SetPieceAt(Square{File: f, Rank: 2}, &Piece{Color: Black, Kind: 'S'})
+		}
+		st.Hands[Black]['P'] = pawns
+		return st
+	}
+
+	tests := []struct {
+		name   string
+		pawns  int
+		rule   DeclarationRule
+		modify func(st *State)
+		points int
+		want   Ending
+	}{
+		{name: "27 win", pawns: 10, rule: DeclarationRule27, points: 28, want: EndingDeclarationWin},
+		{name: "27 short", pawns: 9, rule: DeclarationRule27, points: 27, want: EndingIllegalDeclaration},
+		{name: "24 draw", pawns: 10, rule: DeclarationRule24, points: 28, want: EndingJishogi},
+		{name: "24 win", pawns: 13, rule: DeclarationRule24, points: 31, want: EndingDeclarationWin},
+		{name: "24 short", pawns: 5, rule: DeclarationRule24, points: 23, want: EndingIllegalDeclaration},
+		{
+			name: "king outside zone", pawns: 10, rule: DeclarationRule27, points: 28,
+			modify: func(st *State) {
+				st.SetPieceAt(Square{File: 5, Rank: 2}, nil)
+				st.SetPieceAt(Square{File: 5, Rank: 4}, &Piece{Color: Black, Kind: 'K'})
+			},
+			want: EndingIllegalDeclaration,
+		},
+		{
+			name: "too few pieces in zone", pawns: 11, rule: DeclarationRule27, points: 28,
+			modify: func(st *State) {
+				// 金を1枚、敵陣の外へ
+				st.SetPieceAt(Square{File: 3, Rank: 1}, nil)
+				st.SetPieceAt(Square{File: 3, Rank: 5}, &Piece{Color: Black, Kind: 'G'})
+			},
+			want: EndingIllegalDeclaration,
+		},
+		{
+			name: "in check", pawns: 10, rule: DeclarationRule27, points: 28,
+			modify: func(st *State) {
+				st.SetPieceAt(Square{File: 5, Rank: 8}, &Piece{Color: White, Kind: 'R'})
+			},
+			want: EndingIllegalDeclaration,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			st := setup(tc.pawns)
+			if tc.modify != nil {
+				tc.modify(st)
+			}
+			res := st.EvaluateDeclaration(tc.rule)
+			if res.Points != tc.points {
+				t.Fatalf("points=%d want %d", res.Points, tc.points)
+			}
+			if res.Ending != tc.want {
+				t.Fatalf("ending=%v want %v (%+v)", res.Ending, tc.want, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package domain
+
+// Ending は手順の終わり方（KIF の「まで N 手で …」に対応する）。
+type Ending int
+
+const (
+	EndingNone           Ending = iota // 終局していない（中断）
+	EndingCheckmate                    // 手番側が詰んでいる
+	EndingSennichite                   // 千日手（同一局面4回）
+	EndingPerpetualCheck               // 連続王手の千日手（王手をかけ続けた側の負け）
+
+	// 以下は局面からは決まらず、宣言などで明示する終局
+	EndingDeclarationWin     // 入玉宣言勝ち（手番側の勝ち）
+	EndingJishogi            // 持将棋（引き分け）
+	EndingIllegalDeclaration // 条件を満たさない入玉宣言（手番側の反則負け）
+
+	EndingConditionMate // 条件（協力詰など）どおりの側が詰んだ
+	EndingResign        // 手番側の投了（手番側の負け）
+	EndingIllegalMove   // 宣言以外の反則による手番側の負け（棋譜の「反則負け」・%ILLEGAL_MOVE）
+)
+
+// IsCheckmate は手番側が詰んでいるか（王手がかかっていて合法手が無い）を返す。
+func (s *State) IsCheckmate() bool {
+	return s.IsInCheck(s.SideToMove) && !s.HasLegalMove()
+}
+
+// DetectEnding は現在の局面から終局の種類を判定する。
+// 条件があるときは、条件の側（Goal）が詰んでいれば EndingConditionMate を返す。
+func (s *State) DetectEnding() Ending {
+	if s.IsCheckmate() {
+		if s.Condition != ConditionNone && s.SideToMove == s.Condition.Goal() {
+			return EndingConditionMate
+		}
+		return EndingCheckmate
+	}
+	if repeated, perpetual, _ := s.Sennichite(); repeated {
+		if perpetual {
+			return EndingPerpetualCheck
+		}
+		return EndingSennichite
+	}
+	return EndingNone
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "testing"
+
+func TestIsCheckmate(t *testing.T) {
+	// [checkmate]
+	// 目的：頭金は詰み、逃げ道・合駒があれば詰みではないことを確認する。
+	headGold := func() *State {
+		st := NewStateEmpty()
+		st.SideToMove = White
+		st.SetPieceAt(Square{File: 5, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+		st.SetPieceAt(Square{File: 5, Rank: 2}, &Piece{Color: Black, Kind: 'G'})
+		st.SetPieceAt(Square{File: 5, Rank: 3}, &Piece{Color: Black, Kind: 'P'})
+		return st
+	}
+
+	st := headGold()
+	if !st.IsCheckmate() || st.DetectEnding() != EndingCheckmate {
+		t.Fatalf("head gold: expected checkmate")
+	}
+
+	// 金に紐が無ければ玉で取れる
+	st = headGold()
+	st.SetPieceAt(Square{File: 5, Rank: 3}, nil)
+	if st.IsCheckmate() {
+		t.Fatalf("unprotected gold: expected not checkmate")
+	}
+
+	// 遠くからの飛車の王手は合駒で防げる
+	st = NewStateEmpty()
+	st.SideToMove = White
+	st.Hands[White] = map[PieceKind]int{'P': 1}
+	st.SetPieceAt(Square{File: 1, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+	st.SetPieceAt(Square{File: 1, Rank: 9}, &Piece{Color: Black, Kind: 'R'})
+	st.SetPieceAt(Square{File: 2, Rank: 9}, &Piece{Color: Black, Kind: 'R'})
+	if !st.IsInCheck(White) {
+		t.Fatalf("expected check")
+	}
+	if st.IsCheckmate() {
+		t.Fatalf("interposition available: expected not checkmate")
+	}
+	st.Hands[White] = map[PieceKind]int{}
+	if !st.IsCheckmate() {
+		t.Fatalf("no interposition: expected checkmate")
+	}
+
+	// 王手がかかっていなければ、合法手が無くても詰みではない
+	st = NewStateEmpty()
+	st.SideToMove = White
+	st.SetPieceAt(Square{File: 1, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+	st.SetPieceAt(Square{File: 3, Rank: 2}, &Piece{Color: Black, Kind: 'G'})
+	st.SetPieceAt(Square{File: 2, Rank: 4}, &Piece{Color: Black, Kind: 'N'})
+	if st.HasLegalMove() || st.IsCheckmate() {
+		t.Fatalf("stalemate: expected no legal moves and not checkmate")
+	}
+}
@@ -0,0 +1,186 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 指し手・入力の反則を表す型付きエラーと、その日本語/英語のメッセージ。
+// 呼び出し側は文字列ではなく errors.Is(err, ErrDoublePawn) や RuleError.Code で分岐する。
+
+// ErrorCode は反則の種類（値は外部に出しても変えない）。
+type ErrorCode int
+
+const (
+	CodeOutOfBoard           ErrorCode = 1  // 盤外のマス
+	CodeDropOccupied         ErrorCode = 2  // 駒のあるマスへの打ち
+	CodeNoPieceInHand        ErrorCode = 3  // 持駒に無い駒の打ち
+	CodeDoublePawn           ErrorCode = 4  // 二歩
+	CodeDeadPieceDrop        ErrorCode = 5  // 行き所のない駒の打ち
+	CodeDropPromote          ErrorCode = 6  // 打った駒を成る
+	CodeMissingFrom          ErrorCode = 7  // 移動元の指定が無い
+	CodeNoPieceAtFrom        ErrorCode = 8  // 移動元に駒が無い
+	CodeWrongColor           ErrorCode = 9  // 相手の駒を動かした
+	CodeKindMismatch         ErrorCode = 10 // 指定の駒種と移動元の駒が違う
+	CodeIllegalMove          ErrorCode = 11 // 駒の動きとして行けない
+	CodeCaptureOwn           ErrorCode = 12 // 自分の駒を取る
+	CodeNotPromotable        ErrorCode = 13 // 成れない駒（金・玉）を成る
+	CodeAlreadyPromoted      ErrorCode = 14 // 成駒をさらに成る
+	CodePromotionOutsideZone ErrorCode = 15 // 敵陣に関係しない成
+	CodeMustPromote          ErrorCode = 16 // 不成だと行き所のない駒
+	CodeKingInCheck          ErrorCode = 17 // 王手放置・自殺手
+	CodePawnDropMate         ErrorCode = 18 // 打ち歩詰め
+	CodeMustCheck            ErrorCode = 19 // 条件（協力詰など）で王手が必要
+
+	CodeNumericFormat  ErrorCode = 101 // 数字入力が 3〜5 桁でない
+	CodeNumericDrop    ErrorCode = 102 // 3 桁の数字入力が 0 で始まらない
+	CodeNumericRange   ErrorCode = 103 // 数字入力のマスが 1〜9 でない
+	CodeNumericPromote ErrorCode = 104 // 5 桁目が 0/1 でない
+)
+
+// RuleError は反則1件。該当しない項目はゼロ値（From/To は nil）のまま。
+type RuleError struct {
+	Code ErrorCode
+	Side Color     // 指した側
+	Kind PieceKind // 関係する駒種
+	From *Square
+	To   *Square
+	File int // 二歩の筋
+}
+
+// 比較用の値（errors.Is はコードだけを見る）
+var (
+	ErrOutOfBoard           = &RuleError{Code: CodeOutOfBoard}
+	ErrDropOccupied         = &RuleError{Code: CodeDropOccupied}
+	ErrNoPieceInHand        = &RuleError{Code: CodeNoPieceInHand}
+	ErrDoublePawn           = &RuleError{Code: CodeDoublePawn}
+	ErrDeadPieceDrop        = &RuleError{Code: CodeDeadPieceDrop}
+	ErrDropPromote          = &RuleError{Code: CodeDropPromote}
+	ErrMissingFrom          = &RuleError{Code: CodeMissingFrom}
+	ErrNoPieceAtFrom        = &RuleError{Code: CodeNoPieceAtFrom}
+	ErrWrongColor           = &RuleError{Code: CodeWrongColor}
+	ErrKindMismatch         = &RuleError{Code: CodeKindMismatch}
+	ErrIllegalMove          = &RuleError{Code: CodeIllegalMove}
+	ErrCaptureOwn           = &RuleError{Code: CodeCaptureOwn}
+	ErrNotPromotable        = &RuleError{Code: CodeNotPromotable}
+	ErrAlreadyPromoted      = &RuleError{Code: CodeAlreadyPromoted}
+	ErrPromotionOutsideZone = &RuleError{Code: CodePromotionOutsideZone}
+	ErrMustPromote          = &RuleError{Code: CodeMustPromote}
+
+	// ErrKingInCheck は、指した後に自玉へ王手がかかったままになる手（王手放置・自殺手・ピンされた駒の移動）を表す。
+	ErrKingInCheck = &RuleError{Code: CodeKingInCheck}
+	// ErrPawnDropMate は打ち歩詰め（歩を打って相手玉を詰ませる手）を表す。
+	ErrPawnDropMate = &RuleError{Code: CodePawnDropMate}
+	// ErrMustCheck は、条件（協力詰・ばか自殺詰）で先手が王手でない手を指したことを表す。
+	ErrMustCheck = &RuleError{Code: CodeMustCheck}
+
+	ErrNumericFormat  = &RuleError{Code: CodeNumericFormat}
+	ErrNumericDrop    = &RuleError{Code: CodeNumericDrop}
+	ErrNumericRange   = &RuleError{Code: CodeNumericRange}
+	ErrNumericPromote = &RuleError{Code: CodeNumericPromote}
+)
+
+func (e *RuleError) Error() string { return e.Message(LangEN) }
+
+// Is は同じコードの RuleError なら true を返す（ErrDoublePawn などとの比較用）。
+func (e *RuleError) Is(target error) bool {
+	t, ok := target.(*RuleError)
+	return ok && t.Code == e.Code
+}
+
+// Lang はメッセージの言語。
+type Lang int
+
+const (
+	LangJA Lang = iota
+	LangEN
+)
+
+// errorCatalog はコードごとのメッセージ。{kind} {from} {to} {file} を埋める。
+var errorCatalog = map[ErrorCode][2]string{ // [LangJA, LangEN]
+	CodeOutOfBoard:           {"盤外です：{to}", "square is off the board: {to}"},
+	CodeDropOccupied:         {"{to}には駒があるので打てません", "cannot drop on occupied square {to}"},
+	CodeNoPieceInHand:        {"持駒に{kind}がありません", "no {kind} in hand"},
+	CodeDoublePawn:           {"二歩です（{file}筋）", "double pawn on file {file}"},
+	CodeDeadPieceDrop:        {"{to}に{kind}は打てません（行き所のない駒）", "{kind} dropped on {to} could never move"},
+	CodeDropPromote:          {"打った駒は成れません", "a dropped piece cannot promote"},
+	CodeMissingFrom:          {"移動元が指定されていません", "move has no from-square"},
+	CodeNoPieceAtFrom:        {"{from}に駒がありません", "no piece on {from}"},
+	CodeWrongColor:           {"{from}の駒は手番側の駒ではありません", "piece on {from} belongs to the opponent"},
+	CodeKindMismatch:         {"{from}の駒は{kind}ではありません", "piece on {from} is not a {kind}"},
+	CodeIllegalMove:          {"{kind}は{from}から{to}へ動けません", "{kind} cannot move from {from} to {to}"},
+	CodeCaptureOwn:           {"{to}の自分の駒は取れません", "cannot capture own piece on {to}"},
+	CodeNotPromotable:        {"{kind}は成れません", "{kind} cannot promote"},
+	CodeAlreadyPromoted:      {"{from}の駒は成っています", "piece on {from} is already promoted"},
+	CodePromotionOutsideZone: {"{from}から{to}への手では成れません（敵陣の外）", "cannot promote from {from} to {to} outside the promotion zone"},
+	CodeMustPromote:          {"{to}へ行く{kind}は成らなければなりません", "{kind} moving to {to} must promote"},
+	CodeKingInCheck:          {"王手放置です（{to}）", "king left in check ({to})"},
+	CodePawnDropMate:         {"打ち歩詰めです（{to}）", "pawn drop mate ({to})"},
+	CodeMustCheck:            {"王手ではありません（{to}）", "move to {to} must give check"},
+
+	CodeNumericFormat:  {"数字入力は3〜5桁です", "numeric input must be 3..5 digits"},
+	CodeNumericDrop:    {"3桁の入力は 0 で始めてください（打ち）", "3-digit input must start with 0 for drop"},
+	CodeNumericRange:   {"マスは 1〜9 で指定してください", "square out of range"},
+	CodeNumericPromote: {"5桁目は 0 か 1 です", "5th digit must be 0 or 1"},
+}
+
+var kindNamesEN = map[PieceKind]string{
+	'P': "pawn", 'L': "lance", 'N': "knight", 'S': "silver", 'G': "gold", 'B': "bishop", 'R': "rook", 'K': "king",
+}
+
+// Message は lang のメッセージを返す。マスは KIF と同じ「７六」の形で書く。
+func (e *RuleError) Message(lang Lang) string {
+	msgs, ok := errorCatalog[e.Code]
+	if !ok {
+		return fmt.Sprintf("rule error %d", e.Code)
+	}
+	kind := pieceJP[e.Kind]
+	msg := msgs[0]
+	if lang == LangEN {
+		kind = kindNamesEN[e.Kind]
+		msg = msgs[1]
+	}
+	sq := func(p *Square) string {
+		if p == nil {
+			return "?"
+		}
+		return p.KIF()
+	}
+	return strings.NewReplacer(
+		"{kind}", kind,
+		"{from}", sq(e.From),
+		"{to}", sq(e.To),
+		"{file}", fmt.Sprint(e.File),
+	).Replace(msg)
+}
+
+// Localize は err を lang のメッセージにする（RuleError 以外は Error() のまま）。
+func Localize(err error, lang Lang) string {
+	var re *ReplayError
+	if errors.As(err, &re) {
+		if lang == LangJA {
+			return fmt.Sprintf("%d手目: %s", re.Ply, Localize(re.Err, lang))
+		}
+		return fmt.Sprintf("ply %d: %s", re.Ply, Localize(re.Err, lang))
+	}
+	var rule *RuleError
+	if errors.As(err, &rule) {
+		return rule.Message(lang)
+	}
+	return err.Error()
+}
+
+// ruleErr は sq を複製して RuleError を作る。
+func ruleErr(code ErrorCode, side Color, kind PieceKind, from *Square, to *Square) *RuleError {
+	e := &RuleError{Code: code, Side: side, Kind: kind}
+	if from != nil {
+		f := *from
+		e.From = &f
+	}
+	if to != nil {
+		t := *to
+		e.To = &t
+	}
+	return e
+}
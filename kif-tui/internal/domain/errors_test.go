@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRuleError_Codes(t *testing.T) {
+	// [rule-error-codes]
+	// 目的：反則ごとに決まったエラー（コード）が返り、errors.Is で分岐できること。
+	tests := []struct {
+		name  string
+		setup func(st *State)
+		kind  PieceKind
+		from  *Square
+		to    Square
+		prom  bool
+		want  *RuleError
+	}{
+		{
+			name: "double-pawn",
+			setup: func(st *State) {
+				st.SetPieceAt(Square{File: 7, Rank: 7}, &Piece{Color: Black, Kind: 'P'})
+				st.Hands[Black]['P'] = 1
+			},
+			kind: 'P', to: Square{File: 7, Rank: 5}, want: ErrDoublePawn,
+		},
+		{
+			name:  "dead-piece-drop",
+			setup: func(st *State) { st.Hands[Black]['N'] = 1 },
+			kind:  'N', to: Square{File: 5, Rank: 2}, want: ErrDeadPieceDrop,
+		},
+		{
+			name:  "must-promote",
+			setup: func(st *State) { st.SetPieceAt(Square{File: 5, Rank: 2}, &Piece{Color: Black, Kind: 'P'}) },
+			kind:  'P', from: &Square{File: 5, Rank: 2}, to: Square{File: 5, Rank: 1}, want: ErrMustPromote,
+		},
+		{
+			name:  "promotion-outside-zone",
+			setup: func(st *State) { st.SetPieceAt(Square{File: 7, Rank: 7}, &Piece{Color: Black, Kind: 'P'}) },
+			kind:  'P', from: &Square{File: 7, Rank: 7}, to: Square{File: 7, Rank: 6}, prom: true, want: ErrPromotionOutsideZone,
+		},
+		{
+			name:  "illegal-move",
+			setup: func(st *State) { st.SetPieceAt(Square{File: 7, Rank: 7}, &Piece{Color: Black, Kind: 'P'}) },
+			kind:  'P', from: &Square{File: 7, Rank: 7}, to: Square{File: 7, Rank: 5}, want: ErrIllegalMove,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			st := NewStateEmpty()
+			tc.setup(st)
+			err := st.ApplyMoveStrict(tc.kind, tc.from, tc.to, tc.prom, tc.from == nil)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("got %v, want code %d", err, tc.want.Code)
+			}
+			var re *RuleError
+			if !errors.As(err, &re) || re.To == nil || *re.To != tc.to || re.Kind != tc.kind {
+				t.Fatalf("error does not carry the move: %+v", re)
+			}
+		})
+	}
+}
+
+func TestRuleError_Localize(t *testing.T) {
+	// [rule-error-localize]
+	// 目的：日本語・英語のメッセージに KIF 表記のマス（７六）が入ること。
+	st := NewStateEmpty()
+	st.SetPieceAt(Square{File: 7, Rank: 7}, &Piece{Color: Black, Kind: 'P'})
+	err := st.ApplyMoveStrict('P', &Square{File: 7, Rank: 7}, Square{File: 7, Rank: 6}, true, false)
+
+	if got, want := Localize(err, LangJA), "７七から７六への手では成れません（敵陣の外）"; got != want {
+		t.Fatalf("JA: got %q want %q", got, want)
+	}
+	if got, want := Localize(err, LangEN), "cannot promote from ７七 to ７六 outside the promotion zone"; got != want {
+		t.Fatalf("EN: got %q want %q", got, want)
+	}
+
+	rerr := &ReplayError{Ply: 3, Err: err}
+	if got, want := Localize(rerr, LangJA), "3手目: ７七から７六への手では成れません（敵陣の外）"; got != want {
+		t.Fatalf("replay JA: got %q want %q", got, want)
+	}
+
+	if _, _, _, _, perr := ParseNumeric("7"); !errors.Is(perr, ErrNumericFormat) {
+		t.Fatalf("ParseNumeric: got %v, want ErrNumericFormat", perr)
+	}
+}
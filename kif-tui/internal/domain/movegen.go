@@ -0,0 +1,92 @@
+package domain
+
+// 指し手生成。
+// PseudoLegalMoves は駒の動き・二歩・行き所のない駒・成/不成の選択までを反映した手、
+// LegalMoves はそこから王手放置（自殺手）と打ち歩詰めを除いた手を返す。
+// 生成は Position（bitboard.go）で行い、State 側では規則を持たない。
+// 玉が2枚あるなど Position にできない局面（Validate がエラーにする局面）では手を返さない。
+
+// handOrder は打ちを生成する順（持駒表記と同じ 飛角金銀桂香歩）
+var handOrder = []PieceKind{'R', 'B', 'G', 'S', 'N', 'L', 'P'}
+
+// PseudoLegalMoves は手番側の疑似合法手（盤上の移動＋打ち）を返す。
+func (s *State) PseudoLegalMoves() []Move {
+	return s.generate(false, func(PosMove) bool { return true })
+}
+
+// PseudoLegalBoardMoves は手番側の盤上の駒の疑似合法手を返す。
+// 成れる場合は成・不成の両方、不成だと行き所がない場合は成だけを返す。
+func (s *State) PseudoLegalBoardMoves() []Move {
+	return s.generate(false, func(m PosMove) bool { return m.From >= 0 })
+}
+
+// PseudoLegalDrops は手番側の打ちの疑似合法手を返す（二歩・行き所のない駒は除く）。
+func (s *State) PseudoLegalDrops() []Move {
+	return s.generate(false, func(m PosMove) bool { return m.From < 0 })
+}
+
+// LegalMoves は手番側の合法手を返す。
+func (s *State) LegalMoves() []Move {
+	return s.generate(true, func(PosMove) bool { return true })
+}
+
+// HasLegalMove は手番側に合法手が1つでもあるかを返す。
+func (s *State) HasLegalMove() bool {
+	p, err := s.Position()
+	return err == nil && p.HasLegalMove()
+}
+
+// generate は Position で手を生成し、keep を満たす手を Move にして返す。
+func (s *State) generate(legal bool, keep func(PosMove) bool) []Move {
+	p, err := s.Position()
+	if err != nil {
+		return nil
+	}
+	var buf [600]PosMove
+	var pms []PosMove
+	if legal {
+		pms = p.LegalMoves(buf[:0])
+	} else {
+		pms = p.PseudoLegalMoves(buf[:0])
+	}
+	out := make([]Move, 0, len(pms))
+	for _, m := range pms {
+		if keep(m) {
+			out = append(out, m.Move())
+		}
+	}
+	return out
+}
+
+// isDeadSquare は、不成の駒 kind を to に置くと以後動けなくなる（行き所のない駒）かを返す。
+func (s *State) isDeadSquare(side Color, kind PieceKind, to Square) bool {
+	return s.Rules().isDeadSquare(side, kind, to)
+}
+
+// Perft は depth 手先までの合法手順の数を返す（指し手生成の検証用）。
+// 局面と Undo/Redo の履歴は呼ぶ前のまま残る。
+func (s *State) Perft(depth int) uint64 {
+	// 探索中の Undo が積む手で、利用者の Redo を上書きしない
+	redo := s.redo
+	defer func() { s.redo = redo }()
+	return s.perft(depth)
+}
+
+func (s *State) perft(depth int) uint64 {
+	if depth <= 0 {
+		return 1
+	}
+	moves := s.LegalMoves()
+	if depth == 1 {
+		return uint64(len(moves))
+	}
+	var n uint64
+	for _, mv := range moves {
+		if err := s.applyMinimal(mv.Kind, mv.From, mv.To, mv.Promote, mv.IsDrop); err != nil {
+			panic("perft: generated move failed to apply: " + err.Error())
+		}
+		n += s.perft(depth - 1)
+		s.Undo()
+	}
+	return n
+}
@@ -0,0 +1,216 @@
+package domain
+
+import "testing"
+
+// perft の参照値。
+// 平手は既知の値（30 / 900 / 25470 / 719731）。
+// 詰将棋の局面の値は、テストの中で strictPerft（全マスへの手を ApplyMoveStrict で試す総当たり）でも数えて確かめる
+// （駒の動きと王手放置は State 側の別の実装で判定する。打ち歩詰めの判定だけは Position と共通）。
+func TestPerft_Hirate(t *testing.T) {
+	want := []uint64{1, 30, 900, 25470}
+	if !testing.Short() {
+		want = append(want, 719731)
+	}
+	for depth, n := range want {
+		st := NewStateHirate()
+		if got := st.Perft(depth); got != n {
+			t.Fatalf("perft(%d)=%d want %d", depth, got, n)
+		}
+	}
+}
+
+func TestPerft_KeepsRedoHistory(t *testing.T) {
+	// [perft-keeps-redo]
+	// 目的：Perft の後も、利用者の Redo（戻した手）がそのまま残り、探索の手が Redo に混ざらないこと。
+	st := NewStateHirate()
+	from := Square{File: 7, Rank: 7}
+	if err := st.ApplyMoveMinimal('P', &from, Square{File: 7, Rank: 6}, false, false); err != nil {
+		t.Fatal(err)
+	}
+	st.Undo()
+	st.Perft(2)
+	if !st.Redo() {
+		t.Fatalf("redo lost after Perft")
+	}
+	if mv := st.Moves[len(st.Moves)-1]; mv.To != (Square{File: 7, Rank: 6}) {
+		t.Fatalf("redo replayed %+v, want ７六歩", mv)
+	}
+	if st.CanRedo() {
+		t.Fatalf("perft moves left on the redo stack")
+	}
+
+	// Redo の無い状態では、Perft の後も Redo できないこと
+	st = NewStateHirate()
+	st.Perft(2)
+	if st.CanRedo() {
+		t.Fatalf("CanRedo after Perft on a fresh state")
+	}
+}
+
+func TestPerft_TsumePositions(t *testing.T) {
+	type placed struct {
+		sq Square
+		p  Piece
+	}
+	tests := []struct {
+		name   string
+		pieces []placed
+		hands  Hands
+		want   []uint64 // depth 1..
+	}{
+		{
+			// [tsume-head-gold]
+			// 後手玉 51、先手の持駒 金1（頭金の形）
+			name:   "head-gold",
+			pieces: []placed{{Square{File: 5, Rank: 1}, Piece{Color: White, Kind: 'K'}}},
+			hands:  Hands{Black: {'G': 1}, White: {}},
+			want:   []uint64{80, 375, 1903},
+		},
+		{
+			// [tsume-pawn-drop-mate]
+			// 12 歩打が打ち歩詰めになる局面（歩打ちの1手が生成されないこと）
+			name: "pawn-drop-mate",
+			pieces: []placed{
+				{Square{File: 1, Rank: 1}, Piece{Color: White, Kind: 'K'}},
+				{Square{File: 3, Rank: 2}, Piece{Color: Black, Kind: 'G'}},
+				{Square{File: 2, Rank: 4}, Piece{Color: Black, Kind: 'N'}},
+			},
+			hands: Hands{Black: {'P': 1}, White: {}},
+			want:  []uint64{76, 9, 680},
+		},
+		{
+			// [tsume-mixed]
+			// 竜・と・桂と双方の持駒がある局面（成/不成の選択、合駒の打ちを含む）
+			name: "mixed",
+			pieces: []placed{
+				{Square{File: 2, Rank: 1}, Piece{Color: White, Kind: 'K'}},
+				{Square{File: 3, Rank: 1}, Piece{Color: White, Kind: 'S'}},
+				{Square{File: 1, Rank: 3}, Piece{Color: White, Kind: 'P'}},
+				{Square{File: 3, Rank: 4}, Piece{Color: Black, Kind: 'R', Prom: true}},
+				{Square{File: 2, Rank: 5}, Piece{Color: Black, Kind: 'N'}},
+				{Square{File: 4, Rank: 3}, Piece{Color: Black, Kind: 'P', Prom: true}},
+			},
+			hands: Hands{Black: {'G': 1, 'S': 1}, White: {'G': 1, 'P': 2}},
+			want:  []uint64{178, 22906},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for i, n := range tc.want {
+				depth := i + 1
+				st := NewStateEmpty()
+				st.SideToMove = Black
+				for _, pl := range tc.pieces {
+					p := pl.p
+					st.SetPieceAt(pl.sq, &p)
+				}
+				for c, m := range tc.hands {
+					for k, v := range m {
+						st.Hands[c][k] = v
+					}
+				}
+				if got := st.Perft(depth); got != n {
+					t.Fatalf("perft(%d)=%d want %d", depth, got, n)
+				}
+				if got := strictPerft(st, depth); got != n {
+					t.Fatalf("strict perft(%d)=%d want %d", depth, got, n)
+				}
+			}
+		})
+	}
+}
+
+func TestLegalMoves_AcceptedByStrict(t *testing.T) {
+	// [legal-moves-strict]
+	// 目的：LegalMoves の各手が ApplyMoveStrict でも受理されることを確認する。
+	st := NewStateHirate()
+	for _, mv := range st.LegalMoves() {
+		if err := st.ApplyMoveStrict(mv.Kind, mv.From, mv.To, mv.Promote, mv.IsDrop); err != nil {
+			t.Fatalf("strict rejected generated move %+v: %v", mv, err)
+		}
+		st.Undo()
+	}
+}
+
+func TestLegalMoves_PromotionChoices(t *testing.T) {
+	// [promotion-choices]
+	// 目的：敵陣に入る銀は成・不成の2手、1段目に進む歩は成の1手だけが生成されることを確認する。
+	st := NewStateEmpty()
+	st.SideToMove = Black
+	st.SetPieceAt(Square{File: 5, Rank: 4}, &Piece{Color: Black, Kind: 'S'})
+	st.SetPieceAt(Square{File: 1, Rank: 2}, &Piece{Color: Black, Kind: 'P'})
+
+	count := map[Square]int{}
+	for _, mv := range st.LegalMoves() {
+		count[mv.To]++
+		if mv.To == (Square{File: 1, Rank: 1}) && !mv.Promote {
+			t.Fatalf("pawn to last rank without promotion: %+v", mv)
+		}
+	}
+	if n := count[Square{File: 5, Rank: 3}]; n != 2 {
+		t.Fatalf("silver 54->53: got %d moves, want 2", n)
+	}
+	if n := count[Square{File: 1, Rank: 1}]; n != 1 {
+		t.Fatalf("pawn 12->11: got %d moves, want 1", n)
+	}
+}
+
+// strictMoves は、盤上の全マスへの移動・打ちを ApplyMoveStrict で試し、通った手を返す。
+func strictMoves(st *State) map[PosMove]bool {
+	ss := st.CloneSnapshot()
+	v := st.Rules()
+	out := map[PosMove]bool{}
+	try := func(mv Move) {
+		tmp := NewStateEmpty()
+		tmp.RestoreSnapshot(ss)
+		if tmp.ApplyMoveStrict(mv.Kind, mv.From, mv.To, mv.Promote, mv.IsDrop) == nil {
+			out[PosMoveOf(mv)] = true
+		}
+	}
+	for tf := 1; tf <= v.Files; tf++ {
+		for tr := 1; tr <= v.Ranks; tr++ {
+			to := Square{File: tf, Rank: tr}
+			for k, n := range st.Hands[st.SideToMove] {
+				if n > 0 {
+					try(Move{IsDrop: true, Kind: k, To: to})
+				}
+			}
+			for f := 1; f <= v.Files; f++ {
+				for r := 1; r <= v.Ranks; r++ {
+					from := Square{File: f, Rank: r}
+					p := st.PieceAt(from)
+					if p == nil || p.Color != st.SideToMove {
+						continue
+					}
+					try(Move{Kind: p.Kind, From: &from, To: to})
+					try(Move{Kind: p.Kind, From: &from, To: to, Promote: true})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// strictPerft は strictMoves だけで数えた perft（指し手生成を使わない参照値）。
+func strictPerft(st *State, depth int) uint64 {
+	if depth <= 0 {
+		return 1
+	}
+	moves := strictMoves(st)
+	if depth == 1 {
+		return uint64(len(moves))
+	}
+	ss := st.CloneSnapshot()
+	var n uint64
+	for m := range moves {
+		mv := m.Move()
+		next := NewStateEmpty()
+		next.RestoreSnapshot(ss)
+		if err := next.ApplyMoveStrict(mv.Kind, mv.From, mv.To, mv.Promote, mv.IsDrop); err != nil {
+			panic("strictPerft: " + err.Error())
+		}
+		n += strictPerft(next, depth-1)
+	}
+	return n
+}
@@ -0,0 +1,101 @@
+package domain
+
+// 駒の動き（利き）の定義。
+// 方向は先手視点で表し、Rank が減る方向を「前」とする。後手は 180° 回転して使う。
+
+type dir struct {
+	df, dr int
+}
+
+var (
+	dirsPawn   = []dir{{0, -1}}
+	dirsKnight = []dir{{-1, -2}, {1, -2}}
+	dirsSilver = []dir{{0, -1}, {-1, -1}, {1, -1}, {-1, 1}, {1, 1}}
+	dirsGold   = []dir{{0, -1}, {-1, -1}, {1, -1}, {-1, 0}, {1, 0}, {0, 1}}
+	dirsKing   = []dir{{0, -1}, {-1, -1}, {1, -1}, {-1, 0}, {1, 0}, {0, 1}, {-1, 1}, {1, 1}}
+	dirsDiag   = []dir{{-1, -1}, {1, -1}, {-1, 1}, {1, 1}}
+	dirsOrth   = []dir{{0, -1}, {-1, 0}, {1, 0}, {0, 1}}
+)
+
+// moveRule: steps は1マスだけ動ける方向、slides は駒に当たるまで走れる方向。
+type moveRule struct {
+	steps  []dir
+	slides []dir
+}
+
+// ruleOf は駒種（成/不成を含む14種）ごとの動きを返す。
+func ruleOf(kind PieceKind, prom bool) moveRule {
+	if prom {
+		switch kind {
+		case 'P', 'L', 'N', 'S':
+			// と・成香・成桂・成銀は金と同じ動き
+			return moveRule{steps: dirsGold}
+		case 'B':
+			// 馬 = 角 + 縦横1マス
+			return moveRule{steps: dirsOrth, slides: dirsDiag}
+		case 'R':
+			// 竜 = 飛 + 斜め1マス
+			return moveRule{steps: dirsDiag, slides: dirsOrth}
+		}
+	}
+	switch kind {
+	case 'P':
+		return moveRule{steps: dirsPawn}
+	case 'L':
+		return moveRule{slides: dirsPawn}
+	case 'N':
+		return moveRule{steps: dirsKnight}
+	case 'S':
+		return moveRule{steps: dirsSilver}
+	case 'G':
+		return moveRule{steps: dirsGold}
+	case 'B':
+		return moveRule{slides: dirsDiag}
+	case 'R':
+		return moveRule{slides: dirsOrth}
+	case 'K':
+		return moveRule{steps: dirsKing}
+	}
+	return moveRule{}
+}
+
+// orient は先手視点の方向を c 側の向きに変換する。
+func orient(c Color, d dir) dir {
+	if c == White {
+		return dir{df: -d.df, dr: -d.dr}
+	}
+	return d
+}
+
+func (s *State) onBoard(sq Square) bool {
+	return s.Rules().Contains(sq)
+}
+
+// canReach は、盤上の駒 p が from から to へ動けるかを返す。
+// 見るのは駒の動きと走り駒の遮りだけで、to にある駒の色や王手は見ない。
+func (s *State) canReach(p *Piece, from, to Square) bool {
+	if from == to || !s.onBoard(to) {
+		return false
+	}
+	rule := ruleOf(p.Kind, p.Prom)
+	for _, d := range rule.steps {
+		d = orient(p.Color, d)
+		if from.File+d.df == to.File && from.Rank+d.dr == to.Rank {
+			return true
+		}
+	}
+	for _, d := range rule.slides {
+		d = orient(p.Color, d)
+		sq := Square{File: from.File + d.df, Rank: from.Rank + d.dr}
+		for s.onBoard(sq) {
+			if sq == to {
+				return true
+			}
+			if s.PieceAt(sq) != nil {
+				break
+			}
+			sq = Square{File: sq.File + d.df, Rank: sq.Rank + d.dr}
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package domain
+
+import "testing"
+
+func TestApplyMoveStrict_MovementGeometry(t *testing.T) {
+	// [movement-geometry]
+	// 目的：駒種（成駒を含む14種）ごとの動きをStrictで保証する。
+	// 盤上には動かす駒と、遮り用の駒だけを置く。
+	sq := func(f, r int) Square { return Square{File: f, Rank: r} }
+
+	tests := []struct {
+		name    string
+		piece   Piece
+		from    Square
+		to      Square
+		blocker *Square // 途中に置く相手の歩
+		ok      bool
+	}{
+		{name: "pawn forward", piece: Piece{Color: Black, Kind: 'P'}, from: sq(7, 7), to: sq(7, 6), ok: true},
+		{name: "pawn jump", piece: Piece{Color: Black, Kind: 'P'}, from: sq(7, 7), to: sq(1, 1), ok: false},
+		{name: "pawn two squares", piece: Piece{Color: Black, Kind: 'P'}, from: sq(7, 7), to: sq(7, 5), ok: false},
+		{name: "pawn backward", piece: Piece{Color: Black, Kind: 'P'}, from: sq(7, 7), to: sq(7, 8), ok: false},
+		{name: "white pawn forward", piece: Piece{Color: White, Kind: 'P'}, from: sq(3, 3), to: sq(3, 4), ok: true},
+		{name: "white pawn backward", piece: Piece{Color: White, Kind: 'P'}, from: sq(3, 3), to: sq(3, 2), ok: false},
+
+		{name: "lance slide", piece: Piece{Color: Black, Kind: 'L'}, from: sq(1, 9), to: sq(1, 4), ok: true},
+		{name: "lance blocked", piece: Piece{Color: Black, Kind: 'L'}, from: sq(1, 9), to: sq(1, 4), blocker: &Square{File: 1, Rank: 6}, ok: false},
+		{name: "lance backward", piece: Piece{Color: Black, Kind: 'L'}, from: sq(1, 5), to: sq(1, 6), ok: false},
+
+		{name: "knight", piece: Piece{Color: Black, Kind: 'N'}, from: sq(8, 9), to: sq(7, 7), ok: true},
+		{name: "knight straight", piece: Piece{Color: Black, Kind: 'N'}, from: sq(8, 9), to: sq(8, 7), ok: false},
+		{name: "knight jumps over", piece: Piece{Color: Black, Kind: 'N'}, from: sq(8, 9), to: sq(9, 7), blocker: &Square{File: 8, Rank: 8}, ok: true},
+		{name: "white knight", piece: Piece{Color: White, Kind: 'N'}, from: sq(2, 1), to: sq(3, 3), ok: true},
+
+		{name: "silver diagonal back", piece: Piece{Color: Black, Kind: 'S'}, from: sq(5, 5), to: sq(4, 6), ok: true},
+		{name: "silver sideways", piece: Piece{Color: Black, Kind: 'S'}, from: sq(5, 5), to: sq(4, 5), ok: false},
+		{name: "gold sideways", piece: Piece{Color: Black, Kind: 'G'}, from: sq(5, 5), to: sq(4, 5), ok: true},
+		{name: "gold diagonal back", piece: Piece{Color: Black, Kind: 'G'}, from: sq(5, 5), to: sq(4, 6), ok: false},
+		{name: "white gold diagonal forward", piece: Piece{Color: White, Kind: 'G'}, from: sq(5, 5), to: sq(4, 6), ok: true},
+		{name: "king", piece: Piece{Color: Black, Kind: 'K'}, from: sq(5, 5), to: sq(6, 6), ok: true},
+		{name: "king two squares", piece: Piece{Color: Black, Kind: 'K'}, from: sq(5, 5), to: sq(5, 3), ok: false},
+
+		{name: "bishop", piece: Piece{Color: Black, Kind: 'B'}, from: sq(8, 8), to: sq(3, 3), ok: true},
+		{name: "bishop blocked", piece: Piece{Color: Black, Kind: 'B'}, from: sq(8, 8), to: sq(3, 3), blocker: &Square{File: 5, Rank: 5}, ok: false},
+		{name: "bishop captures blocker", piece: Piece{Color: Black, Kind: 'B'}, from: sq(8, 8), to: sq(5, 5), blocker: &Square{File: 5, Rank: 5}, ok: true},
+		{name: "bishop orthogonal", piece: Piece{Color: Black, Kind: 'B'}, from: sq(8, 8), to: sq(8, 7), ok: false},
+		{name: "rook", piece: Piece{Color: Black, Kind: 'R'}, from: sq(2, 8), to: sq(2, 2), ok: true},
+		{name: "rook blocked", piece: Piece{Color: Black, Kind: 'R'}, from: sq(2, 8), to: sq(7, 8), blocker: &Square{File: 5, Rank: 8}, ok: false},
+		{name: "rook diagonal", piece: Piece{Color: Black, Kind: 'R'}, from: sq(2, 8), to: sq(3, 7), ok: false},
+
+		{name: "tokin sideways", piece: Piece{Color: Black, Kind: 'P', Prom: true}, from: sq(5, 5), to: sq(6, 5), ok: true},
+		{name: "tokin diagonal back", piece: Piece{Color: Black, Kind: 'P', Prom: true}, from: sq(5, 5), to: sq(6, 6), ok: false},
+		{name: "promoted lance backward", piece: Piece{Color: Black, Kind: 'L', Prom: true}, from: sq(5, 5), to: sq(5, 6), ok: true},
+		{name: "promoted lance no slide", piece: Piece{Color: Black, Kind: 'L', Prom: true}, from: sq(5, 5), to: sq(5, 3), ok: false},
+		{name: "promoted knight no jump", piece: Piece{Color: Black, Kind: 'N', Prom: true}, from: sq(5, 5), to: sq(4, 3), ok: false},
+		{name: "promoted silver sideways", piece: Piece{Color: Black, Kind: 'S', Prom: true}, from: sq(5, 5), to: sq(4, 5), ok: true},
+		{name: "horse orthogonal step", piece: Piece{Color: Black, Kind: 'B', Prom: true}, from: sq(5, 5), to: sq(5, 4), ok: true},
+		{name: "horse orthogonal two", piece: Piece{Color: Black, Kind: 'B', Prom: true}, from: sq(5, 5), to: sq(5, 3), ok: false},
+		{name: "horse diagonal blocked", piece: Piece{Color: Black, Kind: 'B', Prom: true}, from: sq(5, 5), to: sq(2, 2), blocker: &Square{File: 3, Rank: 3}, ok: false},
+		{name: "dragon diagonal step", piece: Piece{Color: Black, Kind: 'R', Prom: true}, from: sq(5, 5), to: sq(4, 4), ok: true},
+		{name: "dragon diagonal two", piece: Piece{Color: Black, Kind: 'R', Prom: true}, from: sq(5, 5), to: sq(3, 3), ok: false},
+		{name: "dragon orthogonal blocked", piece: Piece{Color: Black, Kind: 'R', Prom: true}, from: sq(5, 5), to: sq(5, 1), blocker: &Square{File: 5, Rank: 3}, ok: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			st := NewStateEmpty()
+			st.SideToMove = tc.piece.Color
+			p := tc.piece
+			st.SetPieceAt(tc.from, &p)
+			if tc.blocker != nil {
+				other := Black
+				if tc.piece.Color == Black {
+					other = White
+				}
+				st.SetPieceAt(*tc.blocker, &Piece{Color: other, Kind: 'P'})
+			}
+
+			from := tc.from
+			err := st.ApplyMoveStrict(tc.piece.Kind, &from, tc.to, false, false)
+			if tc.ok && err != nil {
+				t.Fatalf("expected nil, got %v", err)
+			}
+			if !tc.ok && err == nil {
+				t.Fatalf("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestApplyMoveStrict_KindMismatchIsError(t *testing.T) {
+	// [kind-mismatch]
+	// 目的：移動元の駒と異なる駒種を指定した場合はエラーになることを確認する。
+	st := NewStateEmpty()
+	st.SideToMove = Black
+	st.SetPieceAt(Square{File: 7, Rank: 7}, &Piece{Color: Black, Kind: 'P'})
+
+	from := Square{File: 7, Rank: 7}
+	err := st.ApplyMoveStrict('R', &from, Square{File: 7, Rank: 6}, false, false)
+	if err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
+
+func TestApplyMoveStrict_PromotedPieceOnLastRankIsOK(t *testing.T) {
+	// [promoted-last-rank]
+	// 目的：成駒（と）は1段目へ不成で動ける（成の強制は未成駒だけ）ことを確認する。
+	st := NewStateEmpty()
+	st.SideToMove = Black
+	st.SetPieceAt(Square{File: 7, Rank: 2}, &Piece{Color: Black, Kind: 'P', Prom: true})
+
+	from := Square{File: 7, Rank: 2}
+	if err := st.ApplyMoveStrict('P', &from, Square{File: 7, Rank: 1}, false, false); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	// 成駒をさらに成ることはできない
+	st = NewStateEmpty()
+	st.SideToMove = Black
+	st.SetPieceAt(Square{File: 7, Rank: 2}, &Piece{Color: Black, Kind: 'P', Prom: true})
+	if err := st.ApplyMoveStrict('P', &from, Square{File: 7, Rank: 1}, true, false); err == nil {
+		t.Fatalf("expected error, got nil")
+	}
+}
@@ -1,7 +1,6 @@
 package domain
 
 import (
-	"fmt"
 	"regexp"
 	"strconv"
 )
@@ -18,19 +17,19 @@ var reNumeric = regexp.MustCompile(`^\d{3,5}$`)
 //   - "drop_pick" : from=nil, to is set (kind must be resolved by UI via candidates)
 func ParseNumeric(s string) (tag string, from *Square, to Square, promote bool, err error) {
 	if !reNumeric.MatchString(s) {
-		return "", nil, Square{}, false, fmt.Errorf("numeric input must be 3..5 digits")
+		return "", nil, Square{}, false, ErrNumericFormat
 	}
 
 	switch len(s) {
 	case 3:
 		// drop: 0 f r
 		if s[0] != '0' {
-			return "", nil, Square{}, false, fmt.Errorf("3-digit input must start with 0 for drop")
+			return "", nil, Square{}, false, ErrNumericDrop
 		}
 		f, _ := strconv.Atoi(string(s[1]))
 		r, _ := strconv.Atoi(string(s[2]))
 		if f < 1 || f > 9 || r < 1 || r > 9 {
-			return "", nil, Square{}, false, fmt.Errorf("square out of range")
+			return "", nil, Square{}, false, ErrNumericRange
 		}
 		return "drop_pick", nil, Square{File: f, Rank: r}, false, nil
 
@@ -40,7 +39,7 @@ func ParseNumeric(s string) (tag string, from *Square, to Square, promote bool,
 		tf, _ := strconv.Atoi(string(s[2]))
 		tr, _ := strconv.Atoi(string(s[3]))
 		if ff < 1 || ff > 9 || fr < 1 || fr > 9 || tf < 1 || tf > 9 || tr < 1 || tr > 9 {
-			return "", nil, Square{}, false, fmt.Errorf("square out of range")
+			return "", nil, Square{}, false, ErrNumericRange
 		}
 
 		prom := false
@@ -51,7 +50,7 @@ func ParseNumeric(s string) (tag string, from *Square, to Square, promote bool,
 			} else if last == '0' {
 				prom = false
 			} else {
-				return "", nil, Square{}, false, fmt.Errorf("5th digit must be 0 or 1")
+				return "", nil, Square{}, false, ErrNumericPromote
 			}
 		}
 
@@ -60,6 +59,6 @@ func ParseNumeric(s string) (tag string, from *Square, to Square, promote bool,
 		return "move", &fsq, tsq, prom, nil
 
 	default:
-		return "", nil, Square{}, false, fmt.Errorf("unexpected length")
+		return "", nil, Square{}, false, ErrNumericFormat
 	}
 }
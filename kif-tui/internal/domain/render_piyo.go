@@ -1,5 +1,10 @@
 package domain
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Python版 manual_kif.py:board_map_to_piyo を移植した盤面描画（KIF出力の開始局面用）
 
 var pieceJP = map[PieceKind]string{
@@ -23,20 +28,44 @@ var rankKanji = map[int]string{
 	1: "一", 2: "二", 3: "三", 4: "四", 5: "五", 6: "六", 7: "七", 8: "八", 9: "九",
 }
 
-func BoardToPiyo(board *[10][10]*Piece) string {
-	lines := make([]string, 0, 12)
-	lines = append(lines, "  ９ ８ ７ ６ ５ ４ ３ ２ １")
-	lines = append(lines, "+---------------------------+")
-	for r := 1; r <= 9; r++ {
+var fileZenkaku = map[int]string{
+	1: "１", 2: "２", 3: "３", 4: "４", 5: "５", 6: "６", 7: "７", 8: "８", 9: "９",
+}
+
+// KIF はマスを KIF の表記（７六）で返す。盤外のマスは数字のまま返す。
+func (sq Square) KIF() string {
+	f, okF := fileZenkaku[sq.File]
+	r, okR := rankKanji[sq.Rank]
+	if !okF || !okR {
+		return fmt.Sprintf("%d%d", sq.File, sq.Rank)
+	}
+	return f + r
+}
+
+// BoardToPiyo は v の盤の大きさ（nil なら 9×9）で盤面図を描く。
+func BoardToPiyo(board *[10][10]*Piece, v *Variant) string {
+	if v == nil {
+		v = VariantStandard
+	}
+	head := " "
+	for f := v.Files; f >= 1; f-- {
+		head += " " + fileZenkaku[f]
+	}
+	border := "+" + strings.Repeat("-", v.Files*3) + "+"
+	lines := make([]string, 0, v.Ranks+3)
+	lines = append(lines, head)
+	lines = append(lines, border)
+	for r := 1; r <= v.Ranks; r++ {
 		row := ""
-		for f := 9; f >= 1; f-- {
+		for f := v.Files; f >= 1; f-- {
 			p := board[f][r]
 			if p == nil {
 				row += " ・"
 				continue
 			}
 			name := pieceJP[p.Kind]
-			key := [2]interface{}{p.Kind, p.Prom}
+			// 表のキーは文字定数（rune）なので rune にそろえて引く
+			key := [2]interface{}{rune(p.Kind), p.Prom}
 			if v, ok := kindToPyo[key]; ok {
 				name = v
 			}
@@ -48,7 +77,7 @@ func BoardToPiyo(board *[10][10]*Piece) string {
 		}
 		lines = append(lines, "|"+row+"|"+rankKanji[r])
 	}
-	lines = append(lines, "+---------------------------+")
+	lines = append(lines, border)
 	return joinLines(lines)
 }
 
@@ -0,0 +1,42 @@
+package domain
+
+import "fmt"
+
+// 手順の再生（開始局面から指し手を順に適用し直す）。
+// 棋譜の読み込み・KIF の終局判定・開始局面を直した後の手順の再確認などで共通に使う。
+
+// ReplayError は、再生できなかった手とその理由。
+type ReplayError struct {
+	Ply  int  // 何手目か（1 始まり）
+	Move Move // 適用できなかった手
+	Err  error
+}
+
+func (e *ReplayError) Error() string {
+	return fmt.Sprintf("ply %d: %v", e.Ply, e.Err)
+}
+
+func (e *ReplayError) Unwrap() error { return e.Err }
+
+// Replay は start から moves を順に指した State を返す。
+// strict なら ApplyMoveStrict、そうでなければ ApplyMoveMinimal で適用する（手番は start から交互）。
+// 適用できない手があれば、その手の前までを指した State と ReplayError を返す。
+//
+// 戻り値の *ReplayError は error 型の変数に入れずに nil と比べること。
+func Replay(start Snapshot, moves []Move, strict bool) (*State, *ReplayError) {
+	st := NewStateEmpty()
+	st.RestoreSnapshot(start)
+	st.Moves = make([]Move, 0, len(moves))
+	st.ClearHistory()
+
+	apply := st.ApplyMoveMinimal
+	if strict {
+		apply = st.ApplyMoveStrict
+	}
+	for i, mv := range moves {
+		if err := apply(mv.Kind, mv.From, mv.To, mv.Promote, mv.IsDrop); err != nil {
+			return st, &ReplayError{Ply: i + 1, Move: mv, Err: err}
+		}
+	}
+	return st, nil
+}
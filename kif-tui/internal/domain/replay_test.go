@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func sqp(f, r int) *Square { return &Square{File: f, Rank: r} }
+
+func TestReplay_Hirate(t *testing.T) {
+	// [replay-ok]
+	// 目的：合法な手順を strict で再生でき、取った駒・成りが記録されること。
+	moves := []Move{
+		{Kind: 'P', From: sqp(7, 7), To: Square{File: 7, Rank: 6}},
+		{Kind: 'P', From: sqp(3, 3), To: Square{File: 3, Rank: 4}},
+		{Kind: 'B', From: sqp(8, 8), To: Square{File: 2, Rank: 2}, Promote: true},
+	}
+	st, rerr := Replay(NewStateHirate().CloneSnapshot(), moves, true)
+	if rerr != nil {
+		t.Fatalf("replay failed: %v", rerr)
+	}
+	if len(st.Moves) != 3 || st.SideToMove != White {
+		t.Fatalf("moves=%d side=%c, want 3 / W", len(st.Moves), st.SideToMove)
+	}
+	if c := st.Moves[2].Captured; c == nil || c.Kind != 'B' {
+		t.Fatalf("bishop capture not recorded: %+v", c)
+	}
+	if st.Hash() != st.ComputeHash() {
+		t.Fatalf("hash mismatch after replay")
+	}
+}
+
+func TestReplay_ReportsFailingPly(t *testing.T) {
+	// [replay-error]
+	// 目的：strict で反則の手があれば、その手数と理由を返し、直前までの局面を残すこと。
+	moves := []Move{
+		{Kind: 'P', From: sqp(7, 7), To: Square{File: 7, Rank: 6}},
+		{Kind: 'P', From: sqp(5, 3), To: Square{File: 5, Rank: 4}},
+		{Kind: 'B', From: sqp(8, 8), To: Square{File: 3, Rank: 3}}, // 33 の歩を取って王手
+		{Kind: 'K', From: sqp(5, 1), To: Square{File: 4, Rank: 2}}, // 角の利きのまま：王手放置
+	}
+	st, rerr := Replay(NewStateHirate().CloneSnapshot(), moves, true)
+	if rerr == nil {
+		t.Fatalf("expected replay error")
+	}
+	if rerr.Ply != 4 || !errors.Is(rerr, ErrKingInCheck) {
+		t.Fatalf("got ply=%d err=%v, want ply 4 / ErrKingInCheck", rerr.Ply, rerr.Err)
+	}
+	if len(st.Moves) != 3 {
+		t.Fatalf("state should keep the 3 moves before the error, got %d", len(st.Moves))
+	}
+}
+
+func TestReplay_MinimalAcceptsWhatStrictRejects(t *testing.T) {
+	// [replay-minimal]
+	// 目的：strict=false なら駒の動きを見ずに適用する（KIF 表記のテスト用の手順など）。
+	moves := []Move{
+		{Kind: 'P', From: sqp(7, 7), To: Square{File: 7, Rank: 5}}, // 歩の2マス進み
+	}
+	if _, rerr := Replay(NewStateHirate().CloneSnapshot(), moves, true); rerr == nil {
+		t.Fatalf("strict replay should reject a two-square pawn move")
+	}
+	if _, rerr := Replay(NewStateHirate().CloneSnapshot(), moves, false); rerr != nil {
+		t.Fatalf("minimal replay failed: %v", rerr)
+	}
+}
@@ -58,3 +58,138 @@ func NewStateHirate() *State {
 
 	return s
 }
+
+// Handicap は駒落ちの種類（手合割）。駒落ちでは上手（後手）が先に指す。
+type Handicap int
+
+const (
+	HandicapNone       Handicap = iota // 平手
+	HandicapLance                      // 香落ち（1一香）
+	HandicapRightLance                 // 右香落ち（9一香）
+	HandicapBishop                     // 角落ち
+	HandicapRook                       // 飛車落ち
+	HandicapRookLance                  // 飛香落ち
+	HandicapTwo                        // 二枚落ち（飛角）
+	HandicapFour                       // 四枚落ち（＋両香）
+	HandicapSix                        // 六枚落ち（＋両桂）
+	HandicapEight                      // 八枚落ち（＋両銀）
+	HandicapTen                        // 十枚落ち（＋両金）
+)
+
+// Handicaps は手合割の一覧（表示・入力の順）。
+var Handicaps = []Handicap{
+	HandicapNone, HandicapLance, HandicapRightLance, HandicapBishop, HandicapRook, HandicapRookLance,
+	HandicapTwo, HandicapFour, HandicapSix, HandicapEight, HandicapTen,
+}
+
+var handicapNames = map[Handicap]string{
+	HandicapNone:       "平手",
+	HandicapLance:      "香落ち",
+	HandicapRightLance: "右香落ち",
+	HandicapBishop:     "角落ち",
+	HandicapRook:       "飛車落ち",
+	HandicapRookLance:  "飛香落ち",
+	HandicapTwo:        "二枚落ち",
+	HandicapFour:       "四枚落ち",
+	HandicapSix:        "六枚落ち",
+	HandicapEight:      "八枚落ち",
+	HandicapTen:        "十枚落ち",
+}
+
+// handicapRemoved は平手の局面から取り除く上手（後手）の駒のマス。
+var handicapRemoved = map[Handicap][]Square{
+	HandicapLance:      {{File: 1, Rank: 1}},
+	HandicapRightLance: {{File: 9, Rank: 1}},
+	HandicapBishop:     {{File: 2, Rank: 2}},
+	HandicapRook:       {{File: 8, Rank: 2}},
+	HandicapRookLance:  {{File: 8, Rank: 2}, {File: 1, Rank: 1}},
+	HandicapTwo:        {{File: 8, Rank: 2}, {File: 2, Rank: 2}},
+	HandicapFour: {
+		{File: 8, Rank: 2}, {File: 2, Rank: 2},
+		{File: 9, Rank: 1}, {File: 1, Rank: 1},
+	},
+	HandicapSix: {
+		{File: 8, Rank: 2}, {File: 2, Rank: 2},
+		{File: 9, Rank: 1}, {File: 1, Rank: 1}, {File: 8, Rank: 1}, {File: 2, Rank: 1},
+	},
+	HandicapEight: {
+		{File: 8, Rank: 2}, {File: 2, Rank: 2},
+		{File: 9, Rank: 1}, {File: 1, Rank: 1}, {File: 8, Rank: 1}, {File: 2, Rank: 1},
+		{File: 7, Rank: 1}, {File: 3, Rank: 1},
+	},
+	HandicapTen: {
+		{File: 8, Rank: 2}, {File: 2, Rank: 2},
+		{File: 9, Rank: 1}, {File: 1, Rank: 1}, {File: 8, Rank: 1}, {File: 2, Rank: 1},
+		{File: 7, Rank: 1}, {File: 3, Rank: 1}, {File: 6, Rank: 1}, {File: 4, Rank: 1},
+	},
+}
+
+// Name は KIF の手合割の名前を返す。
+func (h Handicap) Name() string { return handicapNames[h] }
+
+// Removed は平手の局面から取り除く上手（後手）の駒のマスを返す。
+func (h Handicap) Removed() []Square {
+	return append([]Square(nil), handicapRemoved[h]...)
+}
+
+// FirstMover は初手を指す側を返す（駒落ちは上手＝後手）。
+func (h Handicap) FirstMover() Color {
+	if h == HandicapNone {
+		return Black
+	}
+	return White
+}
+
+// HandicapByName は手合割の名前（香落ち など）から Handicap を返す。
+func HandicapByName(name string) (Handicap, bool) {
+	for _, h := range Handicaps {
+		if handicapNames[h] == name {
+			return h, true
+		}
+	}
+	return HandicapNone, false
+}
+
+// NewStateHandicap は手合割 h の初期局面を返す（手番は h.FirstMover()）。
+func NewStateHandicap(h Handicap) *State {
+	s := NewStateHirate()
+	for _, sq := range handicapRemoved[h] {
+		s.SetPieceAt(sq, nil)
+	}
+	s.SideToMove = h.FirstMover()
+	return s
+}
+
+// DetectHandicap は、ss の盤と持駒がいずれかの手合割の初期配置と一致すればそれを返す。
+// 手番と手数は見ない（呼び出し側で FirstMover・StartPly と比べる）。
+func DetectHandicap(ss Snapshot) (Handicap, bool) {
+	if ss.Rules() != VariantStandard {
+		return HandicapNone, false
+	}
+	for _, c := range []Color{Black, White} {
+		for _, n := range ss.Hands[c] {
+			if n > 0 {
+				return HandicapNone, false
+			}
+		}
+	}
+	for _, h := range Handicaps {
+		want := NewStateHandicap(h)
+		if sameBoard(&want.Board, &ss.Board) {
+			return h, true
+		}
+	}
+	return HandicapNone, false
+}
+
+func sameBoard(a, b *[10][10]*Piece) bool {
+	for f := 1; f <= 9; f++ {
+		for r := 1; r <= 9; r++ {
+			pa, pb := a[f][r], b[f][r]
+			if (pa == nil) != (pb == nil) || (pa != nil && *pa != *pb) {
+				return false
+			}
+		}
+	}
+	return true
+}
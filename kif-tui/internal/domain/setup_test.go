@@ -0,0 +1,47 @@
+package domain
+
+import "testing"
+
+func TestHandicap_Presets(t *testing.T) {
+	// [handicap-presets]
+	// 目的：各手合割が、落とした枚数・手番どおりに作られ、DetectHandicap で同じ手合割に戻ること。
+	removed := map[Handicap]int{
+		HandicapNone: 0, HandicapLance: 1, HandicapRightLance: 1, HandicapBishop: 1, HandicapRook: 1,
+		HandicapRookLance: 2, HandicapTwo: 2, HandicapFour: 4, HandicapSix: 6, HandicapEight: 8, HandicapTen: 10,
+	}
+	for _, h := range Handicaps {
+		t.Run(h.Name(), func(t *testing.T) {
+			st := NewStateHandicap(h)
+			n := 0
+			for f := 1; f <= 9; f++ {
+				for r := 1; r <= 9; r++ {
+					if p := st.Board[f][r]; p != nil && p.Color == White {
+						n++
+					}
+				}
+			}
+			if got := 20 - n; got != removed[h] {
+				t.Fatalf("removed %d pieces, want %d", got, removed[h])
+			}
+			if st.SideToMove != h.FirstMover() {
+				t.Fatalf("side to move %c, want %c", st.SideToMove, h.FirstMover())
+			}
+			if got, ok := DetectHandicap(st.CloneSnapshot()); !ok || got != h {
+				t.Fatalf("DetectHandicap = %v,%v", got, ok)
+			}
+			if byName, ok := HandicapByName(h.Name()); !ok || byName != h {
+				t.Fatalf("HandicapByName(%s) = %v,%v", h.Name(), byName, ok)
+			}
+			if issues := st.Validate(); HasErrors(issues) {
+				t.Fatalf("preset has errors: %+v", issues)
+			}
+		})
+	}
+
+	// 1手指した局面は手合割の初期局面ではない
+	st := NewStateHandicap(HandicapLance)
+	mustApply(t, st, Square{File: 4, Rank: 1}, Square{File: 3, Rank: 2})
+	if _, ok := DetectHandicap(st.CloneSnapshot()); ok {
+		t.Fatalf("position after a move detected as a preset")
+	}
+}
@@ -26,6 +26,11 @@ type Move struct {
 	From    *Square // nil if drop
 	To      Square
 	Promote bool
+
+	// 以下は ApplyMoveMinimal が指した結果として記録する（指し手生成・入力の段階では空）
+	Captured    *Piece // 取った駒（成駒は Prom=true のまま）。取らなければ nil
+	WasPromoted bool   // 動かした駒が指す前から成っていたか
+	GivesCheck  bool   // 相手玉に王手をかけたか
 }
 
 // Hands[color][kind] = count
@@ -36,8 +41,20 @@ type State struct {
 	Hands      Hands
 	SideToMove Color
 	Moves      []Move
+	StartPly   int       // 開始局面までに指された手数（途中局面から記録するとき。0 なら初手から）
+	Variant    *Variant  // 将棋の種類（nil なら本将棋）
+	Condition  Condition // フェアリー詰将棋の条件（ConditionNone なら通常）
+
+	history   []undoRecord     // 1手ずつ戻すための記録（新しい手が後ろ）
+	redo      []undoRecord     // Undo で戻した手（新しい手を指すと消える）
+	positions []positionRecord // 千日手判定用の局面履歴（開始局面＋各手の後）
+}
 
-	history []Snapshot
+// undoRecord は1手を O(1) で戻すための記録。取った駒・成りの情報は move に入っている。
+type undoRecord struct {
+	move        Move
+	side        Color  // 指した側
+	overwritten *Piece // 打ちで上書きした駒（minimal 適用のみ）。無ければ nil
 }
 
 type Snapshot struct {
@@ -45,6 +62,9 @@ type Snapshot struct {
 	Hands      Hands
 	SideToMove Color
 	Moves      []Move
+	StartPly   int
+	Variant    *Variant
+	Condition  Condition
 }
 
 func NewStateEmpty() *State {
@@ -52,7 +72,6 @@ func NewStateEmpty() *State {
 		Hands:      NewHands(),
 		SideToMove: Black,
 		Moves:      make([]Move, 0),
-		history:    make([]Snapshot, 0),
 	}
 	return s
 }
@@ -92,6 +111,9 @@ func (s *State) CloneSnapshot() Snapshot {
 		Hands:      h,
 		SideToMove: s.SideToMove,
 		Moves:      mv,
+		StartPly:   s.StartPly,
+		Variant:    s.Variant,
+		Condition:  s.Condition,
 	}
 }
 
@@ -119,22 +141,79 @@ func (s *State) RestoreSnapshot(ss Snapshot) {
 	copy(s.Moves, ss.Moves)
 
 	s.SideToMove = ss.SideToMove
+	s.StartPly = ss.StartPly
+	s.Variant = ss.Variant
+	s.Condition = ss.Condition
 }
 
-func (s *State) PushHistory() {
-	s.history = append(s.history, s.CloneSnapshot())
+// Ply は次に指す手が何手目かを返す（StartPly を含む）。
+func (s *State) Ply() int {
+	return s.StartPly + len(s.Moves) + 1
 }
 
+// Undo は直前の1手を戻す。戻した手は Redo でやり直せる。
 func (s *State) Undo() bool {
 	if len(s.history) == 0 {
 		return false
 	}
-	last := s.history[len(s.history)-1]
+	rec := s.history[len(s.history)-1]
 	s.history = s.history[:len(s.history)-1]
-	s.RestoreSnapshot(last)
+
+	mv := rec.move
+	side := rec.side
+	s.ensureHands()
+	if mv.IsDrop {
+		s.Board[mv.To.File][mv.To.Rank] = rec.overwritten
+		s.Hands[side][mv.Kind]++
+	} else {
+		p := *s.Board[mv.To.File][mv.To.Rank]
+		p.Prom = mv.WasPromoted
+		s.Board[mv.From.File][mv.From.Rank] = &p
+		s.Board[mv.To.File][mv.To.Rank] = nil
+		if c := mv.Captured; c != nil {
+			captured := *c
+			s.Board[mv.To.File][mv.To.Rank] = &captured
+			s.Hands[side][c.Kind]--
+			if s.Hands[side][c.Kind] <= 0 {
+				delete(s.Hands[side], c.Kind)
+			}
+		}
+	}
+	if n := len(s.Moves); n > 0 {
+		s.Moves = s.Moves[:n-1]
+	}
+	s.SideToMove = side
+	s.popPosition()
+
+	s.redo = append(s.redo, rec)
 	return true
 }
 
+// Redo は Undo で戻した手を指し直す。
+func (s *State) Redo() bool {
+	if len(s.redo) == 0 {
+		return false
+	}
+	rec := s.redo[len(s.redo)-1]
+	mv := rec.move
+	if err := s.applyMinimal(mv.Kind, mv.From, mv.To, mv.Promote, mv.IsDrop); err != nil {
+		return false
+	}
+	s.redo = s.redo[:len(s.redo)-1]
+	return true
+}
+
+// CanUndo / CanRedo は Undo / Redo できる手があるかを返す。
+func (s *State) CanUndo() bool { return len(s.history) > 0 }
+func (s *State) CanRedo() bool { return len(s.redo) > 0 }
+
+// ClearHistory は undo/redo 履歴と局面履歴を消す（対局開始時など、ここを手順の起点にする）。
+func (s *State) ClearHistory() {
+	s.history = nil
+	s.redo = nil
+	s.positions = nil
+}
+
 func (s *State) PieceAt(sq Square) *Piece {
 	if sq.File < 1 || sq.File > 9 || sq.Rank < 1 || sq.Rank > 9 {
 		return nil
@@ -163,5 +242,6 @@ func (s *State) ClearAll() {
 	s.Hands = NewHands()
 	s.Moves = nil
 	s.SideToMove = Black
-	s.history = nil
+	s.StartPly = 0
+	s.ClearHistory()
 }
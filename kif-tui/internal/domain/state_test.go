@@ -0,0 +1,137 @@
+package domain
+
+import "testing"
+
+// samePosition は盤・持駒・手番・手数が同じかを返す（持駒の 0 枚と未登録は同じとみなす）。
+func samePosition(a, b *State) bool {
+	for f := 1; f <= 9; f++ {
+		for r := 1; r <= 9; r++ {
+			pa, pb := a.Board[f][r], b.Board[f][r]
+			if (pa == nil) != (pb == nil) || (pa != nil && *pa != *pb) {
+				return false
+			}
+		}
+	}
+	for _, c := range []Color{Black, White} {
+		for _, k := range handOrder {
+			if a.Hands[c][k] != b.Hands[c][k] {
+				return false
+			}
+		}
+	}
+	return a.SideToMove == b.SideToMove && len(a.Moves) == len(b.Moves)
+}
+
+func TestUndoRedo_RestoresEachPly(t *testing.T) {
+	// [undo-redo]
+	// 目的：取る手・成る手・打つ手を Undo で1手ずつ正確に戻し、Redo で同じ局面に戻れること。
+	st := NewStateHirate()
+	plies := []func() error{
+		func() error {
+			return st.ApplyMoveStrict('P', &Square{File: 7, Rank: 7}, Square{File: 7, Rank: 6}, false, false)
+		},
+		func() error {
+			return st.ApplyMoveStrict('P', &Square{File: 3, Rank: 3}, Square{File: 3, Rank: 4}, false, false)
+		},
+		func() error {
+			return st.ApplyMoveStrict('B', &Square{File: 8, Rank: 8}, Square{File: 2, Rank: 2}, true, false)
+		},
+		func() error {
+			return st.ApplyMoveStrict('S', &Square{File: 3, Rank: 1}, Square{File: 2, Rank: 2}, false, false)
+		},
+		func() error { return st.ApplyMoveStrict('B', nil, Square{File: 4, Rank: 5}, false, true) },
+	}
+
+	// 各手の前の局面を控えておく
+	before := make([]*State, 0, len(plies))
+	for i, ply := range plies {
+		ss := st.CloneSnapshot()
+		prev := NewStateEmpty()
+		prev.RestoreSnapshot(ss)
+		before = append(before, prev)
+		if err := ply(); err != nil {
+			t.Fatalf("ply %d: %v", i+1, err)
+		}
+	}
+	final := NewStateEmpty()
+	final.RestoreSnapshot(st.CloneSnapshot())
+
+	for i := len(plies) - 1; i >= 0; i-- {
+		if !st.Undo() {
+			t.Fatalf("undo of ply %d failed", i+1)
+		}
+		if !samePosition(st, before[i]) {
+			t.Fatalf("after undo of ply %d: position differs", i+1)
+		}
+	}
+	if st.Undo() {
+		t.Fatalf("undo at the start position should fail")
+	}
+
+	for st.Redo() {
+	}
+	if !samePosition(st, final) {
+		t.Fatalf("after redoing all plies: position differs")
+	}
+	if st.Hash() != st.ComputeHash() {
+		t.Fatalf("hash mismatch after redo")
+	}
+}
+
+func TestRedo_ClearedByNewMove(t *testing.T) {
+	// [redo-cleared]
+	// 目的：Undo の後に別の手を指すと Redo できなくなること。
+	st := NewStateHirate()
+	mustApply(t, st, Square{File: 7, Rank: 7}, Square{File: 7, Rank: 6})
+	if !st.Undo() || !st.CanRedo() {
+		t.Fatalf("expected undo to leave a redo entry")
+	}
+	mustApply(t, st, Square{File: 2, Rank: 7}, Square{File: 2, Rank: 6})
+	if st.CanRedo() || st.Redo() {
+		t.Fatalf("redo should be cleared by a new move")
+	}
+}
+
+func TestMoveRecord_CaptureAndCheckFlags(t *testing.T) {
+	// [move-record]
+	// 目的：指した手に、取った駒（成駒のまま）・指す前の成り・王手の有無が記録されること。
+	st := NewStateEmpty()
+	st.SetPieceAt(Square{File: 5, Rank: 9}, &Piece{Color: Black, Kind: 'K'})
+	st.SetPieceAt(Square{File: 5, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+	st.SetPieceAt(Square{File: 2, Rank: 8}, &Piece{Color: Black, Kind: 'R', Prom: true})
+	st.SetPieceAt(Square{File: 2, Rank: 3}, &Piece{Color: White, Kind: 'P', Prom: true})
+
+	// 龍で と を取る（王手ではない）
+	if err := st.ApplyMoveStrict('R', &Square{File: 2, Rank: 8}, Square{File: 2, Rank: 3}, false, false); err != nil {
+		t.Fatalf("capture: %v", err)
+	}
+	mv := st.Moves[0]
+	if mv.Captured == nil || mv.Captured.Kind != 'P' || !mv.Captured.Prom || mv.Captured.Color != White {
+		t.Fatalf("captured = %+v, want promoted white pawn", mv.Captured)
+	}
+	if !mv.WasPromoted || mv.GivesCheck {
+		t.Fatalf("WasPromoted=%v GivesCheck=%v, want true/false", mv.WasPromoted, mv.GivesCheck)
+	}
+
+	mustApply(t, st, Square{File: 5, Rank: 1}, Square{File: 4, Rank: 1})
+	if m := st.Moves[1]; m.Captured != nil || m.WasPromoted {
+		t.Fatalf("king move recorded as capture/promoted: %+v", m)
+	}
+
+	// 龍を 21 へ：41 の玉に横から王手
+	mustApply(t, st, Square{File: 2, Rank: 3}, Square{File: 2, Rank: 1})
+	if !st.Moves[2].GivesCheck {
+		t.Fatalf("rook move to 21 should give check")
+	}
+
+	// 戻すと と が盤に戻り、持駒から消える
+	st.Undo()
+	st.Undo()
+	st.Undo()
+	if p := st.PieceAt(Square{File: 2, Rank: 3}); p == nil || p.Color != White || !p.Prom {
+		t.Fatalf("undo did not restore the captured tokin: %+v", p)
+	}
+	if st.Hands[Black]['P'] != 0 {
+		t.Fatalf("captured pawn left in hand after undo")
+	}
+}
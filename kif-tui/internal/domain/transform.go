@@ -0,0 +1,69 @@
+package domain
+
+// EDIT 用の局面全体の変形（左右反転・先後反転・平行移動）。
+// 変形した局面では手順が意味を失うので、手順と undo/千日手の履歴は消す。
+
+// MirrorFiles は盤を左右反転する（f筋 → Files+1-f 筋）。
+func (s *State) MirrorFiles() {
+	v := s.Rules()
+	var b [10][10]*Piece
+	for f := 1; f <= v.Files; f++ {
+		for r := 1; r <= v.Ranks; r++ {
+			b[v.Files+1-f][r] = s.Board[f][r]
+		}
+	}
+	s.Board = b
+	s.resetRecord()
+}
+
+// FlipColors は先後反転する（盤を180度回して駒の色・持駒・手番を入れ替える）。
+func (s *State) FlipColors() {
+	v := s.Rules()
+	var b [10][10]*Piece
+	for f := 1; f <= v.Files; f++ {
+		for r := 1; r <= v.Ranks; r++ {
+			p := s.Board[f][r]
+			if p == nil {
+				continue
+			}
+			cp := *p
+			cp.Color = p.Color.Opponent()
+			b[v.Files+1-f][v.Ranks+1-r] = &cp
+		}
+	}
+	s.Board = b
+	s.ensureHands()
+	s.Hands[Black], s.Hands[White] = s.Hands[White], s.Hands[Black]
+	s.SideToMove = s.SideToMove.Opponent()
+	s.resetRecord()
+}
+
+// Shift は盤上の駒をすべて df 筋・dr 段ずらす（正なら筋・段の数が増える方向）。
+// 盤外に出る駒があれば、その駒の RuleError（ErrOutOfBoard）を返し、局面は変えない。
+func (s *State) Shift(df, dr int) error {
+	v := s.Rules()
+	var b [10][10]*Piece
+	for f := 1; f <= v.Files; f++ {
+		for r := 1; r <= v.Ranks; r++ {
+			p := s.Board[f][r]
+			if p == nil {
+				continue
+			}
+			from := Square{File: f, Rank: r}
+			to := Square{File: f + df, Rank: r + dr}
+			if !v.Contains(to) {
+				return ruleErr(CodeOutOfBoard, p.Color, p.Kind, &from, &to)
+			}
+			b[to.File][to.Rank] = p
+		}
+	}
+	s.Board = b
+	s.resetRecord()
+	return nil
+}
+
+// resetRecord は手順と履歴を消す（局面を作り直したとき用）。
+func (s *State) resetRecord() {
+	s.Moves = nil
+	s.ClearHistory()
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransform_MirrorAndFlip(t *testing.T) {
+	// [transform-mirror-flip]
+	// 目的：左右反転を2回・先後反転を2回すると元に戻り、平手は先後反転しても同じ配置になること。
+	st := NewStateEmpty()
+	st.SetPieceAt(Square{File: 1, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+	st.SetPieceAt(Square{File: 2, Rank: 3}, &Piece{Color: Black, Kind: 'S', Prom: true})
+	st.Hands[Black]['G'] = 2
+	st.Hands[White]['P'] = 1
+	orig := st.CloneSnapshot()
+
+	st.MirrorFiles()
+	if p := st.PieceAt(Square{File: 9, Rank: 1}); p == nil || p.Kind != 'K' || p.Color != White {
+		t.Fatalf("mirror: 91 = %+v, want white king", p)
+	}
+	if p := st.PieceAt(Square{File: 8, Rank: 3}); p == nil || p.Kind != 'S' || !p.Prom {
+		t.Fatalf("mirror: 83 = %+v, want promoted silver", p)
+	}
+	st.MirrorFiles()
+	if !sameBoard(&st.Board, &orig.Board) {
+		t.Fatalf("mirror twice changed the board")
+	}
+
+	st.FlipColors()
+	if p := st.PieceAt(Square{File: 9, Rank: 9}); p == nil || p.Kind != 'K' || p.Color != Black {
+		t.Fatalf("flip: 99 = %+v, want black king", p)
+	}
+	if st.Hands[White]['G'] != 2 || st.Hands[Black]['P'] != 1 {
+		t.Fatalf("flip: hands not swapped: %+v", st.Hands)
+	}
+	if st.SideToMove != White {
+		t.Fatalf("flip: side to move %c, want W", st.SideToMove)
+	}
+	st.FlipColors()
+	if !sameBoard(&st.Board, &orig.Board) || st.Hands[Black]['G'] != 2 || st.SideToMove != Black {
+		t.Fatalf("flip twice did not restore the position")
+	}
+
+	hirate := NewStateHirate()
+	hirate.FlipColors()
+	want := NewStateHirate()
+	if !sameBoard(&hirate.Board, &want.Board) {
+		t.Fatalf("flipped hirate differs from hirate")
+	}
+}
+
+func TestTransform_Shift(t *testing.T) {
+	// [transform-shift]
+	// 目的：全駒を平行移動でき、盤外に出る駒があればエラーで局面を変えないこと。
+	st := NewStateEmpty()
+	st.SetPieceAt(Square{File: 1, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+	st.SetPieceAt(Square{File: 2, Rank: 3}, &Piece{Color: Black, Kind: 'G'})
+	st.ApplyMoveMinimal('G', &Square{File: 2, Rank: 3}, Square{File: 2, Rank: 2}, false, false)
+	st.Undo()
+
+	if err := st.Shift(3, 2); err != nil {
+		t.Fatalf("shift: %v", err)
+	}
+	if p := st.PieceAt(Square{File: 4, Rank: 3}); p == nil || p.Kind != 'K' {
+		t.Fatalf("shift: 43 = %+v, want king", p)
+	}
+	if p := st.PieceAt(Square{File: 5, Rank: 5}); p == nil || p.Kind != 'G' {
+		t.Fatalf("shift: 55 = %+v, want gold", p)
+	}
+	if st.CanRedo() {
+		t.Fatalf("shift kept the redo history")
+	}
+
+	before := st.CloneSnapshot()
+	err := st.Shift(0, -3)
+	if !errors.Is(err, ErrOutOfBoard) {
+		t.Fatalf("shift off board: err=%v, want ErrOutOfBoard", err)
+	}
+	var re *RuleError
+	if errors.As(err, &re) && (re.From == nil || *re.From != (Square{File: 4, Rank: 3})) {
+		t.Fatalf("shift off board: from=%v, want 43", re.From)
+	}
+	if !sameBoard(&st.Board, &before.Board) {
+		t.Fatalf("failed shift changed the board")
+	}
+}
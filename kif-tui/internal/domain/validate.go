@@ -0,0 +1,129 @@
+package domain
+
+import "fmt"
+
+// 局面の検査（EDIT で作った局面を対局・出題に使う前のチェック）。
+
+// PieceSet は一組の駒の枚数（成駒は元の駒として数える）。
+var PieceSet = map[PieceKind]int{
+	'R': 2, 'B': 2, 'G': 4, 'S': 4, 'N': 4, 'L': 4, 'P': 18, 'K': 2,
+}
+
+type IssueCode int
+
+const (
+	IssueMissingKing     IssueCode = iota // 玉が無い
+	IssueDuplicateKing                    // 玉が2枚以上
+	IssueDoublePawn                       // 二歩
+	IssueDeadPiece                        // 行き所のない駒（1段目の歩・香、1〜2段目の桂）
+	IssueTooManyPieces                    // 一組の枚数を超えている
+	IssueOpponentInCheck                  // 手番でない側に王手がかかっている
+)
+
+type Severity int
+
+const (
+	SeverityWarning Severity = iota // 詰将棋では普通にある（攻方の玉が無い など）
+	SeverityError                   // 将棋として成り立たない
+)
+
+// Issue は Validate が見つけた問題1件。
+type Issue struct {
+	Code     IssueCode
+	Severity Severity
+	Color    Color
+	Kind     PieceKind
+	Square   *Square // 該当マス（盤全体・持駒の問題なら nil）
+	Message  string
+}
+
+// Validate は局面の問題を列挙する。問題が無ければ空を返す。
+func (s *State) Validate() []Issue {
+	s.ensureHands()
+	issues := make([]Issue, 0)
+
+	counts := map[PieceKind]int{}
+	kings := map[Color][]Square{}
+	pawnFiles := map[Color]map[int]int{Black: {}, White: {}}
+
+	for f := 1; f <= 9; f++ {
+		for r := 1; r <= 9; r++ {
+			p := s.Board[f][r]
+			if p == nil {
+				continue
+			}
+			sq := Square{File: f, Rank: r}
+			counts[p.Kind]++
+			if p.Kind == 'K' {
+				kings[p.Color] = append(kings[p.Color], sq)
+			}
+			if p.Kind == 'P' && !p.Prom {
+				pawnFiles[p.Color][f]++
+			}
+			if !p.Prom && s.isDeadSquare(p.Color, p.Kind, sq) {
+				issues = append(issues, Issue{
+					Code: IssueDeadPiece, Severity: SeverityError,
+					Color: p.Color, Kind: p.Kind, Square: &sq,
+					Message: fmt.Sprintf("%c:%c at %d%d can never move", p.Color, p.Kind, f, r),
+				})
+			}
+		}
+	}
+
+	for _, c := range []Color{Black, White} {
+		switch n := len(kings[c]); {
+		case n == 0:
+			issues = append(issues, Issue{
+				Code: IssueMissingKing, Severity: SeverityWarning, Color: c, Kind: 'K',
+				Message: fmt.Sprintf("%c has no king", c),
+			})
+		case n > 1:
+			sq := kings[c][1]
+			issues = append(issues, Issue{
+				Code: IssueDuplicateKing, Severity: SeverityError, Color: c, Kind: 'K', Square: &sq,
+				Message: fmt.Sprintf("%c has %d kings", c, n),
+			})
+		}
+		for f := 1; f <= 9; f++ {
+			if pawnFiles[c][f] > 1 {
+				issues = append(issues, Issue{
+					Code: IssueDoublePawn, Severity: SeverityError, Color: c, Kind: 'P',
+					Message: fmt.Sprintf("%c has %d pawns on file %d", c, pawnFiles[c][f], f),
+				})
+			}
+		}
+		for k, n := range s.Hands[c] {
+			counts[k] += n
+		}
+	}
+
+	set := s.Rules().PieceSet
+	for _, k := range []PieceKind{'K', 'R', 'B', 'G', 'S', 'N', 'L', 'P'} {
+		if counts[k] > set[k] {
+			issues = append(issues, Issue{
+				Code: IssueTooManyPieces, Severity: SeverityError, Kind: k,
+				Message: fmt.Sprintf("too many %c: %d (max %d)", k, counts[k], set[k]),
+			})
+		}
+	}
+
+	if other := s.SideToMove.Opponent(); s.IsInCheck(other) {
+		ksq, _ := s.KingSquare(other)
+		issues = append(issues, Issue{
+			Code: IssueOpponentInCheck, Severity: SeverityError, Color: other, Kind: 'K', Square: &ksq,
+			Message: fmt.Sprintf("%c (not to move) is already in check", other),
+		})
+	}
+
+	return issues
+}
+
+// HasErrors は issues に SeverityError が含まれるかを返す。
+func HasErrors(issues []Issue) bool {
+	for _, is := range issues {
+		if is.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
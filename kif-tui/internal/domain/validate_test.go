@@ -0,0 +1,117 @@
+package domain
+
+import "testing"
+
+func issueCodes(issues []Issue) map[IssueCode]Severity {
+	out := map[IssueCode]Severity{}
+	for _, is := range issues {
+		out[is.Code] = is.Severity
+	}
+	return out
+}
+
+func TestValidate_Hirate(t *testing.T) {
+	// [validate-hirate]
+	// 目的：平手の初期局面には問題が無いこと。
+	if issues := NewStateHirate().Validate(); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %+v", issues)
+	}
+}
+
+func TestValidate_Issues(t *testing.T) {
+	// [validate-issues]
+	// 目的：各種の壊れた局面を、それぞれの IssueCode と重大度で報告すること。
+	tests := []struct {
+		name     string
+		setup    func(st *State)
+		code     IssueCode
+		severity Severity
+	}{
+		{
+			name: "missing king (tsume attacker)",
+			setup: func(st *State) {
+				st.SetPieceAt(Square{File: 5, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+			},
+			code: IssueMissingKing, severity: SeverityWarning,
+		},
+		{
+			name: "duplicate king",
+			setup: func(st *State) {
+				st.SetPieceAt(Square{File: 5, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+				st.SetPieceAt(Square{File: 1, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+			},
+			code: IssueDuplicateKing, severity: SeverityError,
+		},
+		{
+			name: "double pawn",
+			setup: func(st *State) {
+				st.SetPieceAt(Square{File: 7, Rank: 7}, &Piece{Color: Black, Kind: 'P'})
+				st.SetPieceAt(Square{File: 7, Rank: 5}, &Piece{Color: Black, Kind: 'P'})
+			},
+			code: IssueDoublePawn, severity: SeverityError,
+		},
+		{
+			name: "black pawn on rank 1",
+			setup: func(st *State) {
+				st.SetPieceAt(Square{File: 7, Rank: 1}, &Piece{Color: Black, Kind: 'P'})
+			},
+			code: IssueDeadPiece, severity: SeverityError,
+		},
+		{
+			name: "white knight on rank 8",
+			setup: func(st *State) {
+				st.SetPieceAt(Square{File: 2, Rank: 8}, &Piece{Color: White, Kind: 'N'})
+			},
+			code: IssueDeadPiece, severity: SeverityError,
+		},
+		{
+			name: "too many rooks (board + hands)",
+			setup: func(st *State) {
+				st.SetPieceAt(Square{File: 2, Rank: 8}, &Piece{Color: Black, Kind: 'R', Prom: true})
+				st.Hands[Black]['R'] = 1
+				st.Hands[White]['R'] = 1
+			},
+			code: IssueTooManyPieces, severity: SeverityError,
+		},
+		{
+			name: "side not to move in check",
+			setup: func(st *State) {
+				st.SetPieceAt(Square{File: 5, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+				st.SetPieceAt(Square{File: 5, Rank: 2}, &Piece{Color: Black, Kind: 'G'})
+			},
+			code: IssueOpponentInCheck, severity: SeverityError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			st := NewStateEmpty()
+			st.SideToMove = Black
+			tc.setup(st)
+			issues := st.Validate()
+			sev, ok := issueCodes(issues)[tc.code]
+			if !ok {
+				t.Fatalf("issue %v not reported: %+v", tc.code, issues)
+			}
+			if sev != tc.severity {
+				t.Fatalf("severity=%v want %v", sev, tc.severity)
+			}
+			if HasErrors(issues) != (tc.severity == SeverityError) {
+				t.Fatalf("HasErrors mismatch: %+v", issues)
+			}
+		})
+	}
+}
+
+func TestValidate_PromotedPawnOnLastRankIsOK(t *testing.T) {
+	// [validate-tokin]
+	// 目的：と金は1段目にあってもよく、二歩にも数えないこと。
+	st := NewStateEmpty()
+	st.SetPieceAt(Square{File: 5, Rank: 9}, &Piece{Color: Black, Kind: 'K'})
+	st.SetPieceAt(Square{File: 5, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+	st.SetPieceAt(Square{File: 7, Rank: 1}, &Piece{Color: Black, Kind: 'P', Prom: true})
+	st.SetPieceAt(Square{File: 7, Rank: 7}, &Piece{Color: Black, Kind: 'P'})
+	if issues := st.Validate(); len(issues) != 0 {
+		t.Fatalf("unexpected issues: %+v", issues)
+	}
+}
@@ -0,0 +1,109 @@
+package domain
+
+// 将棋の種類（盤の大きさ・成れる段・駒の組）。
+// State.Variant が nil なら本将棋。盤の配列は [10][10] のまま、使うマスだけを盤とみなす。
+
+// Variant はルールの違い（盤・敵陣・一組の駒）をまとめたもの。
+type Variant struct {
+	Name      string            // KIF の手合割に書く名前（本将棋は空）
+	Files     int               // 筋の数（1..Files）
+	Ranks     int               // 段の数（1..Ranks）
+	ZoneDepth int               // 敵陣の段数
+	PieceSet  map[PieceKind]int // 一組の駒の枚数（成駒は元の駒として数える）
+}
+
+var (
+	// VariantStandard は本将棋（9×9、敵陣3段）。
+	VariantStandard = &Variant{Files: 9, Ranks: 9, ZoneDepth: 3, PieceSet: PieceSet}
+
+	// VariantMinishogi は 5五将棋（5×5、敵陣1段、香・桂なし）。
+	VariantMinishogi = &Variant{
+		Name: "5五将棋", Files: 5, Ranks: 5, ZoneDepth: 1,
+		PieceSet: map[PieceKind]int{'R': 2, 'B': 2, 'G': 2, 'S': 2, 'P': 2, 'K': 2},
+	}
+)
+
+// Contains は sq がこの盤の中かを返す。
+func (v *Variant) Contains(sq Square) bool {
+	return sq.File >= 1 && sq.File <= v.Files && sq.Rank >= 1 && sq.Rank <= v.Ranks
+}
+
+// inZone は sq が side から見た敵陣かを返す。
+func (v *Variant) inZone(side Color, sq Square) bool {
+	if side == Black {
+		return sq.Rank >= 1 && sq.Rank <= v.ZoneDepth
+	}
+	return sq.Rank > v.Ranks-v.ZoneDepth && sq.Rank <= v.Ranks
+}
+
+// isDeadSquare は、不成の駒 kind を to に置くと以後動けなくなる（行き所のない駒）かを返す。
+func (v *Variant) isDeadSquare(side Color, kind PieceKind, to Square) bool {
+	// 先手から見た段（後手は盤を回して数える）
+	r := to.Rank
+	if side == White {
+		r = v.Ranks + 1 - to.Rank
+	}
+	switch kind {
+	case 'P', 'L':
+		return r == 1
+	case 'N':
+		return r <= 2
+	}
+	return false
+}
+
+// Rules は局面のルール（nil なら本将棋）を返す。
+func (s *State) Rules() *Variant {
+	if s.Variant == nil {
+		return VariantStandard
+	}
+	return s.Variant
+}
+
+// Rules はスナップショットのルール（nil なら本将棋）を返す。
+func (ss Snapshot) Rules() *Variant {
+	if ss.Variant == nil {
+		return VariantStandard
+	}
+	return ss.Variant
+}
+
+// NewStateMinishogi は 5五将棋の初期局面（先手番）を返す。
+func NewStateMinishogi() *State {
+	s := NewStateEmpty()
+	s.Variant = VariantMinishogi
+	set := func(c Color, k PieceKind, f, r int) {
+		s.SetPieceAt(Square{File: f, Rank: r}, &Piece{Color: c, Kind: k})
+	}
+	// 先手：5五玉 4五金 3五銀 2五角 1五飛 5四歩
+	set(Black, 'K', 5, 5)
+	set(Black, 'G', 4, 5)
+	set(Black, 'S', 3, 5)
+	set(Black, 'B', 2, 5)
+	set(Black, 'R', 1, 5)
+	set(Black, 'P', 5, 4)
+	// 後手：1一玉 2一金 3一銀 4一角 5一飛 1二歩
+	set(White, 'K', 1, 1)
+	set(White, 'G', 2, 1)
+	set(White, 'S', 3, 1)
+	set(White, 'B', 4, 1)
+	set(White, 'R', 5, 1)
+	set(White, 'P', 1, 2)
+	return s
+}
+
+// IsMinishogiStart は ss が 5五将棋の初期配置（持駒なし）かを返す。手番と手数は見ない。
+func IsMinishogiStart(ss Snapshot) bool {
+	if ss.Variant != VariantMinishogi {
+		return false
+	}
+	for _, c := range []Color{Black, White} {
+		for _, n := range ss.Hands[c] {
+			if n > 0 {
+				return false
+			}
+		}
+	}
+	want := NewStateMinishogi()
+	return sameBoard(&want.Board, &ss.Board)
+}
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+// 5五将棋の perft の既知の値（14 / 181 / 2512 / 35401）。
+func TestPerft_Minishogi(t *testing.T) {
+	want := []uint64{1, 14, 181, 2512}
+	if !testing.Short() {
+		want = append(want, 35401)
+	}
+	for depth, n := range want {
+		st := NewStateMinishogi()
+		if got := st.Perft(depth); got != n {
+			t.Fatalf("perft(%d)=%d want %d", depth, got, n)
+		}
+	}
+}
+
+func TestMinishogi_Rules(t *testing.T) {
+	// [minishogi-rules]
+	// 目的：5五将棋では敵陣が最奥の1段だけで、盤外（6筋・6段）へは動けず、歩は1段目で成らなければならないこと。
+	st := NewStateEmpty()
+	st.Variant = VariantMinishogi
+	st.SetPieceAt(Square{File: 3, Rank: 5}, &Piece{Color: Black, Kind: 'K'})
+	st.SetPieceAt(Square{File: 3, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+	st.SetPieceAt(Square{File: 5, Rank: 2}, &Piece{Color: Black, Kind: 'P'})
+	st.SetPieceAt(Square{File: 1, Rank: 3}, &Piece{Color: Black, Kind: 'S'})
+
+	// 3段目は 5五将棋では敵陣ではない
+	from := Square{File: 1, Rank: 3}
+	if err := st.ApplyMoveStrict('S', &from, Square{File: 1, Rank: 2}, true, false); !errors.Is(err, ErrPromotionOutsideZone) {
+		t.Fatalf("silver 13->12 promote: err=%v, want ErrPromotionOutsideZone", err)
+	}
+	// 盤外（6段目）
+	if err := st.ApplyMoveStrict('K', &Square{File: 3, Rank: 5}, Square{File: 3, Rank: 6}, false, false); !errors.Is(err, ErrOutOfBoard) {
+		t.Fatalf("king 35->36: err=%v, want ErrOutOfBoard", err)
+	}
+	// 1段目の歩は不成にできない
+	if err := st.ApplyMoveStrict('P', &Square{File: 5, Rank: 2}, Square{File: 5, Rank: 1}, false, false); !errors.Is(err, ErrMustPromote) {
+		t.Fatalf("pawn 52->51 unpromoted: err=%v, want ErrMustPromote", err)
+	}
+	for _, mv := range st.LegalMoves() {
+		if !st.Rules().Contains(mv.To) {
+			t.Fatalf("generated off-board move: %+v", mv)
+		}
+	}
+}
+
+func TestMinishogi_BoxAndStart(t *testing.T) {
+	// [minishogi-box]
+	// 目的：5五将棋の初期局面は駒箱が空で、検査にエラーがなく、桂・香は駒箱から出せず、Position にしても5五将棋のままであること。
+	st := NewStateMinishogi()
+	if issues := st.Validate(); HasErrors(issues) {
+		t.Fatalf("start has errors: %+v", issues)
+	}
+	if !IsMinishogiStart(st.CloneSnapshot()) {
+		t.Fatalf("IsMinishogiStart = false")
+	}
+	for k, n := range st.Box() {
+		if n != 0 {
+			t.Fatalf("box has %d %c", n, k)
+		}
+	}
+	if err := st.PlaceFromBox(Square{File: 3, Rank: 3}, Piece{Color: Black, Kind: 'N'}); err == nil {
+		t.Fatalf("PlaceFromBox accepted a knight")
+	}
+	if _, ok := DetectHandicap(st.CloneSnapshot()); ok {
+		t.Fatalf("DetectHandicap matched a minishogi position")
+	}
+	p, err := PositionFromSnapshot(st.CloneSnapshot())
+	if err != nil {
+		t.Fatalf("PositionFromSnapshot: %v", err)
+	}
+	if p.Snapshot().Rules() != VariantMinishogi {
+		t.Fatalf("Position lost the minishogi variant")
+	}
+}
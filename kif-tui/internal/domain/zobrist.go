@@ -0,0 +1,188 @@
+package domain
+
+// 局面ハッシュ（Zobrist）と千日手の判定。
+// ハッシュは盤面・持駒・手番から作る。ApplyMoveMinimal で差分更新し、Undo で戻す。
+
+var (
+	zobristBoard [2][16][10][10]uint64 // [color][kind*2+prom][file][rank]
+	zobristHand  [2][8][19]uint64      // [color][kind][count]（count=0 は 0 のまま）
+	zobristSide  uint64                // 後手番のとき XOR する
+)
+
+var zobristKinds = []PieceKind{'P', 'L', 'N', 'S', 'G', 'B', 'R', 'K'}
+
+func init() {
+	// splitmix64（固定シード：実行ごとに同じ表になる）
+	x := uint64(0x9E3779B97F4A7C15)
+	next := func() uint64 {
+		x += 0x9E3779B97F4A7C15
+		z := x
+		z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+		z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+		return z ^ (z >> 31)
+	}
+	for c := 0; c < 2; c++ {
+		for k := 0; k < 16; k++ {
+			for f := 1; f <= 9; f++ {
+				for r := 1; r <= 9; r++ {
+					zobristBoard[c][k][f][r] = next()
+				}
+			}
+		}
+		for k := 0; k < 8; k++ {
+			for n := 1; n < 19; n++ {
+				zobristHand[c][k][n] = next()
+			}
+		}
+	}
+	zobristSide = next()
+}
+
+func zobristColor(c Color) int {
+	if c == White {
+		return 1
+	}
+	return 0
+}
+
+func zobristKind(k PieceKind) int {
+	for i, kk := range zobristKinds {
+		if kk == k {
+			return i
+		}
+	}
+	return 0
+}
+
+func zobristPieceKey(sq Square, p *Piece) uint64 {
+	k := zobristKind(p.Kind) * 2
+	if p.Prom {
+		k++
+	}
+	return zobristBoard[zobristColor(p.Color)][k][sq.File][sq.Rank]
+}
+
+func zobristHandKey(c Color, k PieceKind, n int) uint64 {
+	if n <= 0 || n >= 19 {
+		return 0
+	}
+	return zobristHand[zobristColor(c)][zobristKind(k)][n]
+}
+
+// ComputeHash は現在の局面のハッシュを一から計算する。
+func (s *State) ComputeHash() uint64 {
+	var h uint64
+	for f := 1; f <= 9; f++ {
+		for r := 1; r <= 9; r++ {
+			if p := s.Board[f][r]; p != nil {
+				h ^= zobristPieceKey(Square{File: f, Rank: r}, p)
+			}
+		}
+	}
+	for c, m := range s.Hands {
+		for k, n := range m {
+			h ^= zobristHandKey(c, k, n)
+		}
+	}
+	if s.SideToMove == White {
+		h ^= zobristSide
+	}
+	return h
+}
+
+// Hash は現在の局面のハッシュを返す。対局中は差分更新した値を返す。
+func (s *State) Hash() uint64 {
+	if n := len(s.positions); n > 0 {
+		return s.positions[n-1].hash
+	}
+	return s.ComputeHash()
+}
+
+// positionRecord は手順中に現れた局面の記録。
+type positionRecord struct {
+	hash    uint64
+	side    Color // この局面の手番
+	inCheck bool  // この局面で手番側に王手がかかっている（＝直前の手が王手）
+}
+
+// ensurePositions は、最初の手を指す前に開始局面を記録する。
+func (s *State) ensurePositions() {
+	if len(s.positions) == 0 {
+		s.positions = append(s.positions, positionRecord{
+			hash:    s.ComputeHash(),
+			side:    s.SideToMove,
+			inCheck: s.IsInCheck(s.SideToMove),
+		})
+	}
+}
+
+// pushPosition は手を指した後の局面を記録する（inCheck は手番側に王手がかかっているか）。
+func (s *State) pushPosition(h uint64, inCheck bool) {
+	s.positions = append(s.positions, positionRecord{
+		hash:    h,
+		side:    s.SideToMove,
+		inCheck: inCheck,
+	})
+}
+
+func (s *State) popPosition() {
+	if n := len(s.positions); n > 1 {
+		s.positions = s.positions[:n-1]
+	}
+}
+
+// RepetitionCount は、現在の局面が手順中（ClearHistory 以降）に現れた回数を返す（現在の局面を含む）。
+func (s *State) RepetitionCount() int {
+	if len(s.positions) == 0 {
+		return 1
+	}
+	cur := s.positions[len(s.positions)-1].hash
+	n := 0
+	for _, rec := range s.positions {
+		if rec.hash == cur {
+			n++
+		}
+	}
+	return n
+}
+
+// Sennichite は千日手（同一局面4回）を判定する。
+// 千日手のうち、一方が王手をかけ続けていた場合は perpetual=true と、
+// 王手をかけ続けた側（連続王手の千日手で負けになる側）を checker に返す。
+func (s *State) Sennichite() (repeated bool, perpetual bool, checker Color) {
+	if s.RepetitionCount() < 4 {
+		return false, false, 0
+	}
+	last := len(s.positions) - 1
+	cur := s.positions[last].hash
+
+	// 4回のうち最初に現れた位置から現在までが、繰り返しの区間
+	seen := 0
+	first := last
+	for i := last; i >= 0; i-- {
+		if s.positions[i].hash == cur {
+			seen++
+			first = i
+			if seen == 4 {
+				break
+			}
+		}
+	}
+
+	// 区間内の手について、指した側ごとに「すべて王手だったか」を調べる
+	allCheck := map[Color]bool{Black: true, White: true}
+	for i := first + 1; i <= last; i++ {
+		rec := s.positions[i]
+		mover := rec.side.Opponent()
+		if !rec.inCheck {
+			allCheck[mover] = false
+		}
+	}
+	switch {
+	case allCheck[Black] && !allCheck[White]:
+		return true, true, Black
+	case allCheck[White] && !allCheck[Black]:
+		return true, true, White
+	}
+	return true, false, 0
+}
@@ -0,0 +1,108 @@
+package domain
+
+import "testing"
+
+func mustApply(t *testing.T, st *State, from, to Square) {
+	t.Helper()
+	p := st.PieceAt(from)
+	if p == nil {
+		t.Fatalf("no piece at %v", from)
+	}
+	if err := st.ApplyMoveStrict(p.Kind, &from, to, false, false); err != nil {
+		t.Fatalf("move %v->%v failed: %v", from, to, err)
+	}
+}
+
+func TestHash_IncrementalMatchesFull(t *testing.T) {
+	// [hash-incremental]
+	// 目的：取る手・成る手・打つ手・Undo を通して、差分更新したハッシュが一から計算した値と一致すること。
+	st := NewStateHirate()
+	start := st.Hash()
+
+	steps := []func(){
+		func() { _ = st.ApplyMoveStrict('P', &Square{File: 7, Rank: 7}, Square{File: 7, Rank: 6}, false, false) },
+		func() { _ = st.ApplyMoveStrict('P', &Square{File: 3, Rank: 3}, Square{File: 3, Rank: 4}, false, false) },
+		func() { _ = st.ApplyMoveStrict('B', &Square{File: 8, Rank: 8}, Square{File: 2, Rank: 2}, true, false) },
+		func() { _ = st.ApplyMoveStrict('S', &Square{File: 3, Rank: 1}, Square{File: 2, Rank: 2}, false, false) },
+		func() { _ = st.ApplyMoveStrict('B', nil, Square{File: 4, Rank: 5}, false, true) },
+	}
+	for i, step := range steps {
+		step()
+		if len(st.Moves) != i+1 {
+			t.Fatalf("step %d was not applied", i+1)
+		}
+		if st.Hash() != st.ComputeHash() {
+			t.Fatalf("step %d: incremental hash mismatch", i+1)
+		}
+	}
+
+	for st.Undo() {
+		if st.Hash() != st.ComputeHash() {
+			t.Fatalf("after undo (%d moves): hash mismatch", len(st.Moves))
+		}
+	}
+	if st.Hash() != start {
+		t.Fatalf("hash after undoing all moves differs from start")
+	}
+}
+
+func TestSennichite_FourfoldRepetition(t *testing.T) {
+	// [sennichite]
+	// 目的：玉の往復で同一局面が4回現れたら千日手（連続王手ではない）と判定すること。
+	st := NewStateEmpty()
+	st.SetPieceAt(Square{File: 5, Rank: 9}, &Piece{Color: Black, Kind: 'K'})
+	st.SetPieceAt(Square{File: 5, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+
+	for cycle := 1; cycle <= 3; cycle++ {
+		mustApply(t, st, Square{File: 5, Rank: 9}, Square{File: 5, Rank: 8})
+		mustApply(t, st, Square{File: 5, Rank: 1}, Square{File: 5, Rank: 2})
+		mustApply(t, st, Square{File: 5, Rank: 8}, Square{File: 5, Rank: 9})
+		mustApply(t, st, Square{File: 5, Rank: 2}, Square{File: 5, Rank: 1})
+
+		if got := st.RepetitionCount(); got != cycle+1 {
+			t.Fatalf("cycle %d: RepetitionCount=%d want %d", cycle, got, cycle+1)
+		}
+	}
+
+	repeated, perpetual, _ := st.Sennichite()
+	if !repeated || perpetual {
+		t.Fatalf("expected plain sennichite, got repeated=%v perpetual=%v", repeated, perpetual)
+	}
+	if st.DetectEnding() != EndingSennichite {
+		t.Fatalf("DetectEnding=%v want EndingSennichite", st.DetectEnding())
+	}
+
+	// 1手戻せば千日手ではない
+	st.Undo()
+	if repeated, _, _ := st.Sennichite(); repeated {
+		t.Fatalf("expected no sennichite after undo")
+	}
+}
+
+func TestSennichite_PerpetualCheck(t *testing.T) {
+	// [perpetual-check]
+	// 目的：先手の飛車が王手をかけ続けて同一局面が4回現れたら、連続王手の千日手（先手の負け）と判定すること。
+	st := NewStateEmpty()
+	st.SetPieceAt(Square{File: 5, Rank: 9}, &Piece{Color: Black, Kind: 'K'})
+	st.SetPieceAt(Square{File: 5, Rank: 1}, &Piece{Color: White, Kind: 'K'})
+	st.SetPieceAt(Square{File: 1, Rank: 9}, &Piece{Color: Black, Kind: 'R'})
+
+	// 19→11（王手）52玉
+	mustApply(t, st, Square{File: 1, Rank: 9}, Square{File: 1, Rank: 1})
+	mustApply(t, st, Square{File: 5, Rank: 1}, Square{File: 5, Rank: 2})
+	for cycle := 0; cycle < 4; cycle++ {
+		// 11→12（王手）51玉 12→11（王手）52玉
+		mustApply(t, st, Square{File: 1, Rank: 1}, Square{File: 1, Rank: 2})
+		mustApply(t, st, Square{File: 5, Rank: 2}, Square{File: 5, Rank: 1})
+		mustApply(t, st, Square{File: 1, Rank: 2}, Square{File: 1, Rank: 1})
+		mustApply(t, st, Square{File: 5, Rank: 1}, Square{File: 5, Rank: 2})
+	}
+
+	repeated, perpetual, checker := st.Sennichite()
+	if !repeated || !perpetual || checker != Black {
+		t.Fatalf("expected perpetual check by Black, got repeated=%v perpetual=%v checker=%c", repeated, perpetual, checker)
+	}
+	if st.DetectEnding() != EndingPerpetualCheck {
+		t.Fatalf("DetectEnding=%v want EndingPerpetualCheck", st.DetectEnding())
+	}
+}
@@ -0,0 +1,192 @@
+package kif
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"kif-tui/internal/domain"
+)
+
+// CSA 形式（V2.2）の出力。将棋所・対局サーバ・エンジンが読む形で、
+// 指し手は「+7776FU」のように移動元・移動先と動いた後の駒を書く。
+// 開始局面は平手・駒落ちなら PI、それ以外は P1〜P9 の盤面と P+/P- の持駒で書く。
+
+// ErrCSAVariant は CSA で書けない盤（5五将棋など）を表す。
+var ErrCSAVariant = errors.New("CSA supports standard shogi only")
+
+// CSAOptions は CSA のヘッダ・終局・消費時間の指定。
+type CSAOptions struct {
+	// 対局者名。空なら N+/N- を書かない
+	BlackName string
+	WhiteName string
+
+	Event     string // $EVENT（空なら書かない）
+	StartTime string // $START_TIME。空なら現在時刻
+
+	// Ending は最後に書く終局。EndingNone なら最終局面から判定する（投了・入玉宣言などは明示する）。
+	Ending domain.Ending
+
+	// Times は各手の消費時間（T 行、秒に切り捨て）。足りない手は KIF と同じく1秒とする。
+	Times []time.Duration
+}
+
+// csaPieces は駒種（成っていない／成った）の CSA の2文字。
+var csaPieces = map[domain.PieceKind][2]string{
+	'P': {"FU", "TO"},
+	'L': {"KY", "NY"},
+	'N': {"KE", "NK"},
+	'S': {"GI", "NG"},
+	'G': {"KI", ""},
+	'B': {"KA", "UM"},
+	'R': {"HI", "RY"},
+	'K': {"OU", ""},
+}
+
+// csaHandOrder は持駒を書く順。
+var csaHandOrder = []domain.PieceKind{'R', 'B', 'G', 'S', 'N', 'L', 'P'}
+
+var csaEndings = map[domain.Ending]string{
+	domain.EndingNone:               "%CHUDAN",
+	domain.EndingCheckmate:          "%TSUMI",
+	domain.EndingConditionMate:      "%TSUMI",
+	domain.EndingSennichite:         "%SENNICHITE",
+	domain.EndingPerpetualCheck:     "%SENNICHITE",
+	domain.EndingDeclarationWin:     "%KACHI",
+	domain.EndingJishogi:            "%JISHOGI",
+	domain.EndingIllegalDeclaration: "%ILLEGAL_MOVE",
+	domain.EndingResign:             "%TORYO",
+	domain.EndingIllegalMove:        "%ILLEGAL_MOVE",
+}
+
+// GenerateCSA は start から moves を CSA 形式で書く。5五将棋は ErrCSAVariant、
+// 駒が一組より多い局面は ErrTooManyPieces を返す。
+// 詰将棋など手合割どおりでない局面は、KIF と同じく後手の持駒を残り駒すべて（P-00AL）とする。
+func GenerateCSA(start domain.Snapshot, moves []domain.Move, opt CSAOptions) (string, error) {
+	if start.Rules() != domain.VariantStandard {
+		return "", ErrCSAVariant
+	}
+	out := make([]string, 0, 32+2*len(moves))
+	out = append(out, "V2.2")
+	if opt.BlackName != "" {
+		out = append(out, "N+"+opt.BlackName)
+	}
+	if opt.WhiteName != "" {
+		out = append(out, "N-"+opt.WhiteName)
+	}
+	if opt.Event != "" {
+		out = append(out, "$EVENT:"+opt.Event)
+	}
+	out = append(out, "$START_TIME:"+nameOr(opt.StartTime, NowYYYYMMDDHHMMSS()))
+	if start.Condition != domain.ConditionNone {
+		// CSA には条件の書き方が無いのでコメントに残す
+		out = append(out, "'条件："+start.Condition.Name())
+	}
+
+	goteHand := start.Hands[domain.White]
+	if handicap, standard := standardStart(start); standard {
+		// 駒落ちは取り除く上手の駒を「PI82HI22KA」のように続ける
+		hirate := domain.NewStateHirate()
+		pi := "PI"
+		for _, sq := range handicap.Removed() {
+			pi += csaSquare(sq) + csaPieces[hirate.PieceAt(sq).Kind][0]
+		}
+		out = append(out, pi)
+	} else {
+		out = append(out, csaBoard(&start.Board)...)
+		if h := csaHand(start.Hands[domain.Black]); h != "" {
+			out = append(out, "P+"+h)
+		}
+		var err error
+		goteHand, err = ComputeGoteRemaining(&start.Board, start.Hands[domain.Black], nil)
+		if err != nil {
+			return "", err
+		}
+		if len(goteHand) > 0 {
+			out = append(out, "P-00AL")
+		}
+	}
+	out = append(out, csaColor(start.SideToMove))
+
+	side := start.SideToMove
+	for i, mv := range moves {
+		out = append(out, csaColor(side)+CSAMove(mv))
+		sec := 1
+		if i < len(opt.Times) {
+			sec = int(opt.Times[i] / time.Second)
+		}
+		out = append(out, fmt.Sprintf("T%d", sec))
+		if side == domain.Black {
+			side = domain.White
+		} else {
+			side = domain.Black
+		}
+	}
+
+	if len(moves) > 0 || opt.Ending != domain.EndingNone {
+		ending := opt.Ending
+		if ending == domain.EndingNone {
+			ending = finalEnding(start, moves, goteHand)
+		}
+		out = append(out, csaEndings[ending])
+	}
+	return strings.Join(out, "\n") + "\n", nil
+}
+
+// CSAMove は mv の CSA 表記（先頭の +/- は付けない）を返す。例：7776FU, 0055KA, 2822RY
+func CSAMove(mv domain.Move) string {
+	from := "00"
+	if !mv.IsDrop && mv.From != nil {
+		from = csaSquare(*mv.From)
+	}
+	return from + csaSquare(mv.To) + csaPieceName(mv.Kind, mv.WasPromoted || mv.Promote)
+}
+
+// csaBoard は P1〜P9 の盤面の行を返す（各段 9筋→1筋、空きは " * "）。
+func csaBoard(board *[10][10]*domain.Piece) []string {
+	lines := make([]string, 0, 9)
+	for r := 1; r <= 9; r++ {
+		var b strings.Builder
+		fmt.Fprintf(&b, "P%d", r)
+		for f := 9; f >= 1; f-- {
+			p := board[f][r]
+			if p == nil {
+				b.WriteString(" * ")
+				continue
+			}
+			b.WriteString(csaColor(p.Color) + csaPieceName(p.Kind, p.Prom))
+		}
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// csaHand は持駒を「00HI00FU00FU」のように1枚ずつ書く（無ければ空）。
+func csaHand(hand map[domain.PieceKind]int) string {
+	var b strings.Builder
+	for _, k := range csaHandOrder {
+		for i := 0; i < hand[k]; i++ {
+			b.WriteString("00" + csaPieces[k][0])
+		}
+	}
+	return b.String()
+}
+
+func csaPieceName(kind domain.PieceKind, prom bool) string {
+	if prom && csaPieces[kind][1] != "" {
+		return csaPieces[kind][1]
+	}
+	return csaPieces[kind][0]
+}
+
+func csaSquare(sq domain.Square) string {
+	return fmt.Sprintf("%d%d", sq.File, sq.Rank)
+}
+
+func csaColor(c domain.Color) string {
+	if c == domain.White {
+		return "-"
+	}
+	return "+"
+}
@@ -0,0 +1,145 @@
+package kif
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kif-tui/internal/domain"
+)
+
+func TestCSAMove(t *testing.T) {
+	// [csa-move]
+	// 目的：移動元・移動先・動いた後の駒（成った手・成駒の手・打ち）を CSA の形で書くこと。
+	sq := func(f, r int) domain.Square { return domain.Square{File: f, Rank: r} }
+	tests := []struct {
+		mv   domain.Move
+		want string
+	}{
+		{boardMove('P', sq(7, 7), sq(7, 6), false), "7776FU"},
+		{boardMove('B', sq(8, 8), sq(2, 2), true), "8822UM"},
+		{domain.Move{Kind: 'R', From: &domain.Square{File: 2, Rank: 2}, To: sq(2, 8), WasPromoted: true}, "2228RY"},
+		{boardMove('S', sq(4, 4), sq(3, 3), false), "4433GI"},
+		{dropMove('G', sq(5, 2)), "0052KI"},
+	}
+	for _, tc := range tests {
+		if got := CSAMove(tc.mv); got != tc.want {
+			t.Errorf("CSAMove(%+v) = %s, want %s", tc.mv, got, tc.want)
+		}
+	}
+}
+
+func TestGenerateCSA_Golden(t *testing.T) {
+	// [csa-golden]
+	// 目的：CSA の全体（V2.2・N+/N-・$ 行・PI/P1〜P9・P+/P-・指し手と T 行・% の終局）を固定する。
+	oldNow := NowFunc
+	NowFunc = func() string { return "2000/01/01 00:00:00" }
+	t.Cleanup(func() { NowFunc = oldNow })
+
+	sq := func(f, r int) domain.Square { return domain.Square{File: f, Rank: r} }
+	tests := []struct {
+		name string
+		opt  CSAOptions
+		make func(t *testing.T) (domain.Snapshot, []domain.Move)
+	}{
+		{
+			// [csa-hirate]
+			// 平手は PI だけ。名前・棋戦・消費時間・投了を明示する。
+			name: "hirate",
+			opt: CSAOptions{
+				BlackName: "先手さん", WhiteName: "後手さん", Event: "練習対局", Ending: domain.EndingResign,
+				Times: []time.Duration{5 * time.Second, 62 * time.Second, 3500 * time.Millisecond},
+			},
+			make: func(t *testing.T) (domain.Snapshot, []domain.Move) {
+				start := domain.NewStateHirate().CloneSnapshot()
+				moves := []domain.Move{
+					boardMove('P', sq(7, 7), sq(7, 6), false),
+					boardMove('P', sq(3, 3), sq(3, 4), false),
+					boardMove('B', sq(8, 8), sq(2, 2), true),
+					boardMove('S', sq(3, 1), sq(2, 2), false),
+					dropMove('B', sq(4, 5)),
+				}
+				return start, replay(t, start, moves, true)
+			},
+		},
+		{
+			// [csa-handicap-two]
+			// 駒落ちは PI に取り除く駒を続け、上手（-）から指す。手があって終局しなければ %CHUDAN。
+			name: "handicap-two",
+			make: func(t *testing.T) (domain.Snapshot, []domain.Move) {
+				start := domain.NewStateHandicap(domain.HandicapTwo).CloneSnapshot()
+				return start, replay(t, start, []domain.Move{boardMove('P', sq(3, 3), sq(3, 4), false)}, true)
+			},
+		},
+		{
+			// [csa-tsume]
+			// 詰将棋は P1〜P9・P+ の持駒・P-00AL（残り駒すべて）で書き、詰めば %TSUMI。
+			name: "tsume",
+			make: func(t *testing.T) (domain.Snapshot, []domain.Move) {
+				st := domain.NewStateEmpty()
+				st.SetPieceAt(sq(5, 1), &domain.Piece{Color: domain.White, Kind: 'K'})
+				st.SetPieceAt(sq(5, 3), &domain.Piece{Color: domain.Black, Kind: 'P'})
+				st.SetPieceAt(sq(1, 1), &domain.Piece{Color: domain.Black, Kind: 'R', Prom: true})
+				st.Hands[domain.Black]['G'] = 1
+				st.Hands[domain.Black]['P'] = 2
+				start := st.CloneSnapshot()
+				return start, replay(t, start, []domain.Move{dropMove('G', sq(5, 2))}, true)
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			start, moves := tc.make(t)
+			got, err := GenerateCSA(start, moves, tc.opt)
+			if err != nil {
+				t.Fatalf("GenerateCSA: %v", err)
+			}
+			wantPath := filepath.Join("testdata", tc.name+".golden.csa")
+			if os.Getenv("UPDATE_GOLDEN") == "1" {
+				if err := os.WriteFile(wantPath, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(wantPath)
+			if err != nil {
+				t.Fatalf("read golden failed: %v (set UPDATE_GOLDEN=1 to create)", err)
+			}
+			if got != string(want) {
+				t.Fatalf("golden mismatch.\n--- got ---\n%s\n--- want ---\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestGenerateCSA_Minishogi(t *testing.T) {
+	// [csa-minishogi]
+	// 目的：CSA に書けない 5五将棋は ErrCSAVariant を返すこと。
+	start := domain.NewStateMinishogi().CloneSnapshot()
+	if _, err := GenerateCSA(start, nil, CSAOptions{}); !errors.Is(err, ErrCSAVariant) {
+		t.Fatalf("err = %v, want ErrCSAVariant", err)
+	}
+}
+
+func TestGenerate_TooManyPieces(t *testing.T) {
+	// [too-many-pieces]
+	// 目的：盤上と先手の持駒で一組を超える局面は、後手の持駒を 0 に丸めずに ErrTooManyPieces になること。
+	st := domain.NewStateEmpty()
+	st.SetPieceAt(domain.Square{File: 5, Rank: 1}, &domain.Piece{Color: domain.White, Kind: 'K'})
+	st.SetPieceAt(domain.Square{File: 5, Rank: 9}, &domain.Piece{Color: domain.Black, Kind: 'K'})
+	st.SetPieceAt(domain.Square{File: 5, Rank: 5}, &domain.Piece{Color: domain.Black, Kind: 'G'})
+	st.Hands[domain.Black]['G'] = 4
+	start := st.CloneSnapshot()
+
+	if _, err := GenerateKIF(start, nil, DefaultKIFOptions()); !errors.Is(err, ErrTooManyPieces) {
+		t.Fatalf("GenerateKIF: err = %v, want ErrTooManyPieces", err)
+	}
+	if _, err := GenerateKI2(start, nil, DefaultKIFOptions()); !errors.Is(err, ErrTooManyPieces) {
+		t.Fatalf("GenerateKI2: err = %v, want ErrTooManyPieces", err)
+	}
+	if _, err := GenerateCSA(start, nil, CSAOptions{}); !errors.Is(err, ErrTooManyPieces) {
+		t.Fatalf("GenerateCSA: err = %v, want ErrTooManyPieces", err)
+	}
+}
@@ -20,6 +20,11 @@ var pieceJP = map[domain.PieceKind]string{
 	'P': "歩", 'L': "香", 'N': "桂", 'S': "銀", 'G': "金", 'B': "角", 'R': "飛", 'K': "玉",
 }
 
+// 成駒が動く手の表記（盤面図の 杏/圭/全 とは違い、指し手では 成香/成桂/成銀 と書く）
+var promotedJP = map[domain.PieceKind]string{
+	'P': "と", 'L': "成香", 'N': "成桂", 'S': "成銀", 'B': "馬", 'R': "龍",
+}
+
 var NowFunc = func() string {
 	// Python版: YYYY/MM/DD HH:MM:SS
 	return time.Now().Format("2006/01/02 15:04:05")
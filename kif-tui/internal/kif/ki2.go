@@ -0,0 +1,235 @@
+package kif
+
+import (
+	"strings"
+
+	"kif-tui/internal/domain"
+)
+
+// KI2 形式の出力。指し手に移動元 (77) を書かない代わりに、
+// 同じ駒が複数動ける手には 右・左・直・上・引・寄・打 を付けて区別する。
+// ヘッダと終了行は KIF と同じ。
+
+const ki2MovesPerLine = 6
+
+// GenerateKI2 は start から moves を KI2 形式で書く。
+// 指し手の表記は局面に依るので、start から moves を順に指し直しながら作る。
+// 駒が一組より多い局面は GenerateKIF と同じく ErrTooManyPieces を返す。
+func GenerateKI2(start domain.Snapshot, moves []domain.Move, opt KIFOptions) (string, error) {
+	out, goteHand, err := kifHeader(start, opt)
+	if err != nil {
+		return "", err
+	}
+
+	st := domain.NewStateEmpty()
+	st.RestoreSnapshot(start)
+	st.Hands[domain.White] = map[domain.PieceKind]int{}
+	for k, n := range goteHand {
+		st.Hands[domain.White][k] = n
+	}
+	st.ClearHistory()
+
+	var prevTo *domain.Square
+	tokens := make([]string, 0, ki2MovesPerLine)
+	for _, mv := range moves {
+		mark := "▲"
+		if st.SideToMove == domain.White {
+			mark = "△"
+		}
+		tokens = append(tokens, mark+KI2Move(st, mv, prevTo))
+		if len(tokens) == ki2MovesPerLine {
+			out = append(out, joinKI2(tokens))
+			tokens = tokens[:0]
+		}
+		// 表記だけが目的なので、反則の手もそのまま進める
+		_ = st.ApplyMoveMinimal(mv.Kind, mv.From, mv.To, mv.Promote, mv.IsDrop)
+		to := mv.To
+		prevTo = &to
+	}
+	if len(tokens) > 0 {
+		out = append(out, joinKI2(tokens))
+	}
+
+	if line, ok := endLine(start, moves, goteHand, opt); ok {
+		out = append(out, line)
+	}
+	return joinLines(out) + "\n", nil
+}
+
+// joinKI2 は1行分の指し手を、全角を2桁と数えて14桁ごとにそろえて並べる。
+func joinKI2(tokens []string) string {
+	var b strings.Builder
+	for i, t := range tokens {
+		b.WriteString(t)
+		if i == len(tokens)-1 {
+			break
+		}
+		pad := 14 - 2*len([]rune(t))
+		b.WriteString(strings.Repeat(" ", max(pad, 2)))
+	}
+	return b.String()
+}
+
+// KI2Move は st の局面で手番側が mv を指すときの KI2 表記（▲/△ は付けない）を返す。
+// prevTo は直前の手の移動先（同 の判定用）。
+func KI2Move(st *domain.State, mv domain.Move, prevTo *domain.Square) string {
+	var b strings.Builder
+	if prevTo != nil && *prevTo == mv.To {
+		b.WriteString("同")
+	} else {
+		b.WriteString(SqToKIF(mv.To.File, mv.To.Rank))
+	}
+
+	if mv.IsDrop || mv.From == nil {
+		name := pieceJP[mv.Kind]
+		if b.String() == "同" {
+			b.WriteString("　")
+		}
+		b.WriteString(name)
+		// 同じ駒が盤上から動けるときだけ「打」を書く
+		if len(ki2Candidates(st, mv.Kind, false, mv.To)) > 0 {
+			b.WriteString("打")
+		}
+		return b.String()
+	}
+
+	prom := mv.WasPromoted
+	if p := st.PieceAt(*mv.From); p != nil {
+		prom = p.Prom
+	}
+	name := pieceJP[mv.Kind]
+	if prom {
+		name = promotedJP[mv.Kind]
+	}
+	if b.String() == "同" && len([]rune(name)) == 1 {
+		b.WriteString("　")
+	}
+	b.WriteString(name)
+	b.WriteString(ki2Qualifier(st.SideToMove, mv.Kind, prom, *mv.From, mv.To, ki2Candidates(st, mv.Kind, prom, mv.To)))
+
+	switch canPromote, mustPromote := ki2Promotion(st, *mv.From, mv.To); {
+	case mv.Promote:
+		b.WriteString("成")
+	case canPromote && !mustPromote:
+		b.WriteString("不成")
+	}
+	return b.String()
+}
+
+// ki2Candidates は、手番側の盤上の駒（kind・成りの状態が同じもの）のうち to へ指せるものの移動元を返す。
+func ki2Candidates(st *domain.State, kind domain.PieceKind, prom bool, to domain.Square) []domain.Square {
+	var out []domain.Square
+	seen := map[domain.Square]bool{}
+	for _, lm := range st.LegalMoves() {
+		if lm.IsDrop || lm.To != to || lm.Kind != kind || seen[*lm.From] {
+			continue
+		}
+		if p := st.PieceAt(*lm.From); p == nil || p.Prom != prom {
+			continue
+		}
+		seen[*lm.From] = true
+		out = append(out, *lm.From)
+	}
+	return out
+}
+
+// ki2Promotion は from→to の手で成を選べるか（canPromote）、成が強制か（mustPromote）を返す。
+func ki2Promotion(st *domain.State, from, to domain.Square) (canPromote, mustPromote bool) {
+	var withProm, withoutProm bool
+	for _, lm := range st.LegalMoves() {
+		if lm.IsDrop || *lm.From != from || lm.To != to {
+			continue
+		}
+		if lm.Promote {
+			withProm = true
+		} else {
+			withoutProm = true
+		}
+	}
+	return withProm, withProm && !withoutProm
+}
+
+// ki2Qualifier は cands（自分を含む移動元）の中から from を区別する 右・左・直・上・引・寄 を返す。
+// 1) 上・引・寄 の動作だけで区別できれば動作を書く（竜・馬は 左・右 を先に見る）
+// 2) できなければ 左・右・直（竜・馬は 直 を使わない）で区別する
+// 3) それでも残れば、同じ動作の駒の中での 左・右・直 に動作を続けて書く（例：右上）
+func ki2Qualifier(side domain.Color, kind domain.PieceKind, prom bool, from, to domain.Square, cands []domain.Square) string {
+	if len(cands) < 2 {
+		return ""
+	}
+	dragonOrHorse := prom && (kind == 'R' || kind == 'B')
+	if dragonOrHorse {
+		if h := ki2Horizontal(side, from, to, cands, true); h != "" {
+			return h
+		}
+	}
+	motion := ki2Motion(side, from, to)
+	same := make([]domain.Square, 0, len(cands))
+	for _, c := range cands {
+		if ki2Motion(side, c, to) == motion {
+			same = append(same, c)
+		}
+	}
+	if len(same) == 1 {
+		return motion
+	}
+
+	if h := ki2Horizontal(side, from, to, cands, dragonOrHorse); h != "" {
+		return h
+	}
+	if h := ki2Horizontal(side, from, to, same, dragonOrHorse); h != "" {
+		if h == "直" {
+			return h
+		}
+		return h + motion
+	}
+	return ""
+}
+
+// ki2Motion は side から見た from→to の動作（上・引・寄）を返す。
+func ki2Motion(side domain.Color, from, to domain.Square) string {
+	dy := from.Rank - to.Rank // 先手から見て前へ進むと正
+	if side == domain.White {
+		dy = -dy
+	}
+	switch {
+	case dy > 0:
+		return "上"
+	case dy < 0:
+		return "引"
+	}
+	return "寄"
+}
+
+// ki2Horizontal は cands の中で from が 直（真っすぐ上がる唯一の駒）・左端・右端のどれかを返す（無ければ空）。
+// 左右は指す側から見る（先手は9筋側、後手は1筋側が左）。
+func ki2Horizontal(side domain.Color, from, to domain.Square, cands []domain.Square, noStraight bool) string {
+	left := func(sq domain.Square) int {
+		if side == domain.White {
+			return -sq.File
+		}
+		return sq.File
+	}
+	if !noStraight && from.File == to.File && ki2Motion(side, from, to) == "上" {
+		return "直"
+	}
+	isLeft, isRight := true, true
+	for _, c := range cands {
+		if c == from {
+			continue
+		}
+		if left(c) >= left(from) {
+			isLeft = false
+		}
+		if left(c) <= left(from) {
+			isRight = false
+		}
+	}
+	switch {
+	case isLeft:
+		return "左"
+	case isRight:
+		return "右"
+	}
+	return ""
+}
@@ -0,0 +1,160 @@
+package kif
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kif-tui/internal/domain"
+)
+
+func TestKI2Move_Qualifiers(t *testing.T) {
+	// [ki2-qualifiers]
+	// 目的：同じ駒が複数動ける手に 右・左・直・上・引・寄・打 を付け、成/不成・同　を正しく書くこと。
+	type placed struct {
+		sq domain.Square
+		p  domain.Piece
+	}
+	sq := func(f, r int) domain.Square { return domain.Square{File: f, Rank: r} }
+	bk := func(k domain.PieceKind) domain.Piece { return domain.Piece{Color: domain.Black, Kind: k} }
+	wk := func(k domain.PieceKind) domain.Piece { return domain.Piece{Color: domain.White, Kind: k} }
+	dragon := domain.Piece{Color: domain.Black, Kind: 'R', Prom: true}
+	silverP := domain.Piece{Color: domain.Black, Kind: 'S', Prom: true}
+
+	tests := []struct {
+		name   string
+		side   domain.Color
+		pieces []placed
+		hand   map[domain.PieceKind]int
+		mv     domain.Move
+		prevTo *domain.Square
+		want   string
+	}{
+		{"gold-left", domain.Black, []placed{{sq(6, 9), bk('G')}, {sq(4, 9), bk('G')}}, nil,
+			boardMove('G', sq(6, 9), sq(5, 8), false), nil, "５八金左"},
+		{"gold-right", domain.Black, []placed{{sq(6, 9), bk('G')}, {sq(4, 9), bk('G')}}, nil,
+			boardMove('G', sq(4, 9), sq(5, 8), false), nil, "５八金右"},
+		{"gold-straight", domain.Black, []placed{{sq(6, 9), bk('G')}, {sq(5, 9), bk('G')}, {sq(4, 9), bk('G')}}, nil,
+			boardMove('G', sq(5, 9), sq(5, 8), false), nil, "５八金直"},
+		{"gold-right-of-straight", domain.Black, []placed{{sq(5, 9), bk('G')}, {sq(4, 9), bk('G')}}, nil,
+			boardMove('G', sq(4, 9), sq(5, 8), false), nil, "５八金右"},
+		{"gold-sideways", domain.Black, []placed{{sq(6, 8), bk('G')}, {sq(4, 9), bk('G')}}, nil,
+			boardMove('G', sq(6, 8), sq(5, 8), false), nil, "５八金寄"},
+		{"gold-up", domain.Black, []placed{{sq(6, 8), bk('G')}, {sq(4, 9), bk('G')}}, nil,
+			boardMove('G', sq(4, 9), sq(5, 8), false), nil, "５八金上"},
+		{"white-gold-left", domain.White, []placed{{sq(4, 1), wk('G')}, {sq(6, 1), wk('G')}}, nil,
+			boardMove('G', sq(4, 1), sq(5, 2), false), nil, "５二金左"},
+		{"silver-right-up", domain.Black, []placed{{sq(4, 7), bk('S')}, {sq(6, 7), bk('S')}, {sq(4, 9), bk('S')}, {sq(6, 9), bk('S')}}, nil,
+			boardMove('S', sq(4, 9), sq(5, 8), false), nil, "５八銀右上"},
+		{"silver-left-back", domain.Black, []placed{{sq(4, 7), bk('S')}, {sq(6, 7), bk('S')}, {sq(4, 9), bk('S')}, {sq(6, 9), bk('S')}}, nil,
+			boardMove('S', sq(6, 7), sq(5, 8), false), nil, "５八銀左引"},
+		{"dragon-left", domain.Black, []placed{{sq(9, 9), dragon}, {sq(1, 9), dragon}}, nil,
+			domain.Move{Kind: 'R', From: &domain.Square{File: 9, Rank: 9}, To: sq(5, 9), WasPromoted: true}, nil, "５九龍左"},
+		{"dragon-back", domain.Black, []placed{{sq(5, 1), dragon}, {sq(5, 9), dragon}}, nil,
+			domain.Move{Kind: 'R', From: &domain.Square{File: 5, Rank: 1}, To: sq(5, 5), WasPromoted: true}, nil, "５五龍引"},
+		{"drop-ambiguous", domain.Black, []placed{{sq(6, 9), bk('G')}}, map[domain.PieceKind]int{'G': 1},
+			dropMove('G', sq(5, 8)), nil, "５八金打"},
+		{"drop-plain", domain.Black, []placed{{sq(6, 9), bk('G')}}, map[domain.PieceKind]int{'G': 1},
+			dropMove('G', sq(5, 5)), nil, "５五金"},
+		{"silver-no-promote", domain.Black, []placed{{sq(4, 4), bk('S')}}, nil,
+			boardMove('S', sq(4, 4), sq(3, 3), false), nil, "３三銀不成"},
+		{"silver-promote", domain.Black, []placed{{sq(4, 4), bk('S')}}, nil,
+			boardMove('S', sq(4, 4), sq(3, 3), true), nil, "３三銀成"},
+		{"pawn-forced-promote", domain.Black, []placed{{sq(3, 2), bk('P')}}, nil,
+			boardMove('P', sq(3, 2), sq(3, 1), true), nil, "３一歩成"},
+		{"pawn-outside-zone", domain.Black, []placed{{sq(3, 7), bk('P')}}, nil,
+			boardMove('P', sq(3, 7), sq(3, 6), false), nil, "３六歩"},
+		{"same-single", domain.Black, []placed{{sq(4, 4), bk('S')}, {sq(3, 3), wk('P')}}, nil,
+			boardMove('S', sq(4, 4), sq(3, 3), true), &domain.Square{File: 3, Rank: 3}, "同　銀成"},
+		{"same-promoted", domain.Black, []placed{{sq(4, 4), silverP}, {sq(3, 3), wk('P')}}, nil,
+			domain.Move{Kind: 'S', From: &domain.Square{File: 4, Rank: 4}, To: sq(3, 3), WasPromoted: true}, &domain.Square{File: 3, Rank: 3}, "同成銀"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			st := domain.NewStateEmpty()
+			st.SetPieceAt(sq(1, 1), &domain.Piece{Color: domain.Black, Kind: 'K'})
+			st.SetPieceAt(sq(9, 1), &domain.Piece{Color: domain.White, Kind: 'K'})
+			for _, pl := range tc.pieces {
+				p := pl.p
+				st.SetPieceAt(pl.sq, &p)
+			}
+			for k, n := range tc.hand {
+				st.Hands[domain.Black][k] = n
+			}
+			st.SideToMove = tc.side
+			if got := KI2Move(st, tc.mv, tc.prevTo); got != tc.want {
+				t.Fatalf("KI2Move = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGenerateKI2_Golden(t *testing.T) {
+	// [ki2-golden]
+	// 目的：KI2 の全体（ヘッダ・▲△・1行6手の並び・終了行）を固定する。
+	oldNow := NowFunc
+	NowFunc = func() string { return "2000/01/01 00:00:00" }
+	t.Cleanup(func() { NowFunc = oldNow })
+
+	sq := func(f, r int) domain.Square { return domain.Square{File: f, Rank: r} }
+	tests := []struct {
+		name string
+		make func(t *testing.T) (domain.Snapshot, []domain.Move)
+	}{
+		{
+			// [ki2-hirate]
+			// 角交換から 同　銀・角打ち・金の 右/左 を含む平手の手順
+			name: "hirate",
+			make: func(t *testing.T) (domain.Snapshot, []domain.Move) {
+				start := domain.NewStateHirate().CloneSnapshot()
+				moves := []domain.Move{
+					boardMove('P', sq(7, 7), sq(7, 6), false),
+					boardMove('P', sq(3, 3), sq(3, 4), false),
+					boardMove('B', sq(8, 8), sq(2, 2), true),
+					boardMove('S', sq(3, 1), sq(2, 2), false),
+					dropMove('B', sq(4, 5)),
+					boardMove('G', sq(6, 1), sq(5, 2), false),
+					boardMove('G', sq(6, 9), sq(5, 8), false),
+					boardMove('G', sq(4, 1), sq(4, 2), false),
+				}
+				return start, replay(t, start, moves, true)
+			},
+		},
+		{
+			// [ki2-tsume]
+			// 詰将棋（盤面図つき）の KI2。終了行は KIF と同じ。
+			name: "tsume",
+			make: func(t *testing.T) (domain.Snapshot, []domain.Move) {
+				st := domain.NewStateEmpty()
+				st.SetPieceAt(sq(5, 1), &domain.Piece{Color: domain.White, Kind: 'K'})
+				st.SetPieceAt(sq(5, 3), &domain.Piece{Color: domain.Black, Kind: 'P'})
+				st.Hands[domain.Black]['G'] = 1
+				start := st.CloneSnapshot()
+				return start, replay(t, start, []domain.Move{dropMove('G', sq(5, 2))}, true)
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			start, moves := tc.make(t)
+			got, err := GenerateKI2(start, moves, DefaultKIFOptions())
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantPath := filepath.Join("testdata", tc.name+".golden.ki2")
+			if os.Getenv("UPDATE_GOLDEN") == "1" {
+				if err := os.WriteFile(wantPath, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(wantPath)
+			if err != nil {
+				t.Fatalf("read golden failed: %v (set UPDATE_GOLDEN=1 to create)", err)
+			}
+			if got != string(want) {
+				t.Fatalf("golden mismatch.\n--- got ---\n%s\n--- want ---\n%s", got, want)
+			}
+		})
+	}
+}
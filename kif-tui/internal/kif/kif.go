@@ -1,17 +1,23 @@
 package kif
 
 import (
+	"errors"
 	"fmt"
 
 	"kif-tui/internal/domain"
 )
 
-var totalCounts = map[domain.PieceKind]int{
-	'R': 2, 'B': 2, 'G': 4, 'S': 4, 'N': 4, 'L': 4, 'P': 18, 'K': 2,
-}
+// ErrTooManyPieces は、盤上と先手の持駒だけで一組の駒数を超えている（後手の持駒が負になる）ことを表す。
+var ErrTooManyPieces = errors.New("more pieces than one set")
 
 // compute_gote_remaining(board0, hands0_b) の移植
-func ComputeGoteRemaining(board0 *[10][10]*domain.Piece, senteHand map[domain.PieceKind]int) map[domain.PieceKind]int {
+// 一組の駒数は v（nil なら本将棋）の駒の組を使う。
+// 読み込んだ棋譜などで駒が一組より多ければ、0 に丸めずに ErrTooManyPieces を返す。
+func ComputeGoteRemaining(board0 *[10][10]*domain.Piece, senteHand map[domain.PieceKind]int, v *domain.Variant) (map[domain.PieceKind]int, error) {
+	if v == nil {
+		v = domain.VariantStandard
+	}
+	totalCounts := v.PieceSet
 	used := map[domain.PieceKind]int{}
 	for k := range totalCounts {
 		used[k] = 0
@@ -41,11 +47,14 @@ func ComputeGoteRemaining(board0 *[10][10]*domain.Piece, senteHand map[domain.Pi
 			continue
 		}
 		left := total - used[kind]
+		if left < 0 {
+			return nil, fmt.Errorf("%w: %c is %d over", ErrTooManyPieces, kind, -left)
+		}
 		if left > 0 {
 			rem[kind] = left
 		}
 	}
-	return rem
+	return rem, nil
 }
 
 func HandsDictToPiyo(d map[domain.PieceKind]int) string {
@@ -83,6 +92,9 @@ func KifLineForMinimalMove(idx int, mv domain.Move, prevTo *domain.Square, sec i
 		body = dst + pieceJP[mv.Kind] + "打"
 	} else {
 		name := pieceJP[mv.Kind]
+		if mv.WasPromoted {
+			name = promotedJP[mv.Kind]
+		}
 		if mv.Promote {
 			name += "成"
 		}
@@ -97,6 +109,16 @@ func KifLineForMinimalMove(idx int, mv domain.Move, prevTo *domain.Square, sec i
 
 type KIFOptions struct {
 	HeaderComment string // 互換ヘッダ先頭行
+
+	// Ending は終了行に書く終局。EndingNone なら最終局面から判定する（入玉宣言などは明示する）。
+	Ending domain.Ending
+
+	// 対局者名。空なら 先手/後手（駒落ちは 下手/上手）
+	BlackName string
+	WhiteName string
+
+	// EndTime は「終了日時」に書く値。空なら現在時刻（読み込んだ棋譜を書き戻すとき用）
+	EndTime string
 }
 
 func DefaultKIFOptions() KIFOptions {
@@ -106,28 +128,12 @@ func DefaultKIFOptions() KIFOptions {
 }
 
 // GenerateKIF: Python版 _generate_kif_text 互換
-func GenerateKIF(start domain.Snapshot, moves []domain.Move, opt KIFOptions) string {
-	out := make([]string, 0, 64)
-
-	// --- header ---
-	out = append(out, opt.HeaderComment)
-	out = append(out, "手合割：詰将棋")
-	out = append(out, "先手：先手")
-	out = append(out, "後手：後手")
-
-	// --- start snapshot ---
-	hands0b := start.Hands[domain.Black]
-	if hands0b == nil {
-		hands0b = map[domain.PieceKind]int{}
+// 詰将棋の形で書く局面に一組より多い駒があれば ErrTooManyPieces を返す。
+func GenerateKIF(start domain.Snapshot, moves []domain.Move, opt KIFOptions) (string, error) {
+	out, goteHand, err := kifHeader(start, opt)
+	if err != nil {
+		return "", err
 	}
-
-	goteRem := ComputeGoteRemaining(&start.Board, hands0b)
-
-	out = append(out, "後手の持駒："+HandsDictToPiyo(goteRem))
-	out = append(out, domain.BoardToPiyo(&start.Board))
-	out = append(out, "先手の持駒："+HandsDictToPiyo(hands0b))
-
-	out = append(out, "終了日時："+NowYYYYMMDDHHMMSS())
 	out = append(out, "手数----指手---------消費時間--")
 
 	prevTo := (*domain.Square)(nil)
@@ -135,7 +141,7 @@ func GenerateKIF(start domain.Snapshot, moves []domain.Move, opt KIFOptions) str
 	secPerMove := 1
 
 	for i, mv := range moves {
-		idx := i + 1
+		idx := start.StartPly + i + 1
 		totalSec += secPerMove
 		line, newPrev := KifLineForMinimalMove(idx, mv, prevTo, secPerMove, totalSec)
 
@@ -146,11 +152,150 @@ func GenerateKIF(start domain.Snapshot, moves []domain.Move, opt KIFOptions) str
 		prevTo = newPrev
 	}
 
-	if len(moves) > 0 {
-		out = append(out, fmt.Sprintf("まで%d手で詰み", len(moves)))
+	if line, ok := endLine(start, moves, goteHand, opt); ok {
+		out = append(out, line)
+	}
+
+	return joinLines(out) + "\n", nil
+}
+
+// kifHeader は KIF/KI2 に共通のヘッダ（先頭のコメントから終了日時まで）と、
+// 記録上の後手の持駒（詰将棋なら残り駒すべて）を返す。
+func kifHeader(start domain.Snapshot, opt KIFOptions) ([]string, map[domain.PieceKind]int, error) {
+	out := make([]string, 0, 64)
+
+	// --- start snapshot ---
+	hands0b := start.Hands[domain.Black]
+	if hands0b == nil {
+		hands0b = map[domain.PieceKind]int{}
+	}
+
+	// 平手・駒落ち・5五将棋の初期局面なら、手合割だけ書いて盤面図を省く
+	handicap, standard := standardStart(start)
+	variant := start.Rules()
+
+	// --- header ---
+	out = append(out, opt.HeaderComment)
+	goteHand := start.Hands[domain.White]
+	if standard {
+		if variant != domain.VariantStandard {
+			out = append(out, "手合割："+variant.Name)
+		} else {
+			out = append(out, "手合割："+handicap.Name())
+		}
+		if handicap == domain.HandicapNone {
+			out = append(out, "先手："+nameOr(opt.BlackName, "先手"))
+			out = append(out, "後手："+nameOr(opt.WhiteName, "後手"))
+		} else {
+			out = append(out, "下手："+nameOr(opt.BlackName, "下手"))
+			out = append(out, "上手："+nameOr(opt.WhiteName, "上手"))
+		}
+		out = appendCondition(out, start.Condition)
+	} else {
+		// 5五将棋は途中局面でも種類を手合割に書く
+		if variant != domain.VariantStandard {
+			out = append(out, "手合割："+variant.Name)
+		} else {
+			out = append(out, "手合割：詰将棋")
+		}
+		out = append(out, "先手："+nameOr(opt.BlackName, "先手"))
+		out = append(out, "後手："+nameOr(opt.WhiteName, "後手"))
+		out = appendCondition(out, start.Condition)
+
+		// 詰将棋の慣習：後手の持駒は残り駒すべて
+		var err error
+		goteHand, err = ComputeGoteRemaining(&start.Board, hands0b, variant)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		out = append(out, "後手の持駒："+HandsDictToPiyo(goteHand))
+		out = append(out, domain.BoardToPiyo(&start.Board, variant))
+		out = append(out, "先手の持駒："+HandsDictToPiyo(hands0b))
+		// 途中局面・後手番から始まる記録
+		if start.StartPly > 0 {
+			out = append(out, fmt.Sprintf("手数＝%d", start.StartPly))
+		}
+		if start.SideToMove == domain.White {
+			out = append(out, "後手番")
+		}
+	}
+
+	out = append(out, "終了日時："+nameOr(opt.EndTime, NowYYYYMMDDHHMMSS()))
+	return out, goteHand, nil
+}
+
+// endLine は「まで N 手で …」の終了行を返す。手が無く終局も明示されていなければ書かない。
+func endLine(start domain.Snapshot, moves []domain.Move, goteHand map[domain.PieceKind]int, opt KIFOptions) (string, bool) {
+	if len(moves) == 0 && opt.Ending == domain.EndingNone {
+		return "", false
+	}
+	ending := opt.Ending
+	if ending == domain.EndingNone {
+		ending = finalEnding(start, moves, goteHand)
+	}
+	words := endingWords[ending]
+	if ending == domain.EndingConditionMate {
+		// 条件どおりに詰んだら「まで7手で協力詰」のように条件の名前を書く
+		words = start.Condition.Name()
 	}
+	return fmt.Sprintf("まで%d手で%s", start.StartPly+len(moves), words), true
+}
+
+var endingWords = map[domain.Ending]string{
+	domain.EndingNone:      "中断",
+	domain.EndingCheckmate: "詰み",
+	// 連続王手の千日手は、王手をかけ続けた側の負け
+	domain.EndingSennichite:     "千日手",
+	domain.EndingPerpetualCheck: "連続王手の千日手",
+
+	domain.EndingDeclarationWin:     "入玉勝ち",
+	domain.EndingJishogi:            "持将棋",
+	domain.EndingIllegalDeclaration: "反則負け",
+	domain.EndingResign:             "投了",
+	domain.EndingIllegalMove:        "反則負け",
+}
+
+// nameOr は s が空なら def を返す。
+func nameOr(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
 
-	return joinLines(out) + "\n"
+// appendCondition は条件（協力詰など）があれば「条件：」の行を足す。
+func appendCondition(out []string, c domain.Condition) []string {
+	if c == domain.ConditionNone {
+		return out
+	}
+	return append(out, "条件："+c.Name())
+}
+
+// standardStart は start が手合割どおりの初期局面（手番・手数を含む）かを返す。
+// 5五将棋は初期配置・先手番なら HandicapNone, true を返す。
+func standardStart(start domain.Snapshot) (domain.Handicap, bool) {
+	if start.Rules() == domain.VariantMinishogi {
+		ok := domain.IsMinishogiStart(start) && start.SideToMove == domain.Black && start.StartPly == 0
+		return domain.HandicapNone, ok
+	}
+	h, ok := domain.DetectHandicap(start)
+	if !ok || start.SideToMove != h.FirstMover() || start.StartPly != 0 {
+		return domain.HandicapNone, false
+	}
+	return h, true
+}
+
+// finalEnding は start から moves を再生した最終局面で終局を判定する。
+// 後手の持駒は KIF に書く内容（詰将棋なら残り駒すべて）に合わせる。
+// 再生できない手順は、詰みかどうか確かめられないので中断扱いにする。
+func finalEnding(start domain.Snapshot, moves []domain.Move, goteHand map[domain.PieceKind]int) domain.Ending {
+	start.Hands = domain.Hands{domain.Black: start.Hands[domain.Black], domain.White: goteHand}
+	st, rerr := domain.Replay(start, moves, false)
+	if rerr != nil {
+		return domain.EndingNone
+	}
+	return st.DetectEnding()
 }
 
 func joinLines(lines []string) string {
@@ -0,0 +1,543 @@
+package kif
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/japanese"
+
+	"kif-tui/internal/domain"
+)
+
+// KIF の読み込み。GenerateKIF が書いた棋譜は GenerateKIF(r.Start, r.Moves, r.Options()) で一字一句同じに戻る。
+// ファイルのバイト列は DecodeText で文字列にする（UTF-8・Shift_JIS）。分岐（「変化：」以降）は読まない。
+
+// ErrEncoding は、棋譜ファイルが UTF-8 でも Shift_JIS でもないことを表す。
+var ErrEncoding = errors.New("unsupported text encoding (want UTF-8 or Shift_JIS)")
+
+// DecodeText は棋譜ファイルのバイト列を文字列にする。
+// UTF-8 として正しければそのまま（BOM は Parse などが読み飛ばす）、そうでなければ Shift_JIS（CP932）として読む。
+// どちらとしても読めなければ ErrEncoding を返す。
+func DecodeText(data []byte) (string, error) {
+	if utf8.Valid(data) {
+		return string(data), nil
+	}
+	out, err := japanese.ShiftJIS.NewDecoder().Bytes(data)
+	if err != nil || strings.ContainsRune(string(out), utf8.RuneError) {
+		return "", ErrEncoding
+	}
+	return string(out), nil
+}
+
+// Record は読み込んだ棋譜。
+type Record struct {
+	Start domain.Snapshot
+	Moves []domain.Move // 読んだままの手（Captured などは空。局面に当てるときは domain.Replay を使う）
+	Meta  Metadata
+}
+
+// Metadata は局面・指し手以外の情報。
+type Metadata struct {
+	HeaderComment string            // 先頭の "#" 行
+	Headers       map[string]string // 「キー：値」のヘッダ（手合割・開始日時・棋戦など。持駒は除く）
+	BlackName     string            // 先手（下手）の名前
+	WhiteName     string            // 後手（上手）の名前
+	EndTime       string            // 終了日時
+	Ending        domain.Ending     // 終了行・特殊な指し手から読んだ終局（中断・不明は EndingNone）
+	EndWords      string            // 終了行の「まで N 手で」の後ろ、または特殊な指し手（投了など）
+	Times         []MoveTime        // 各手の消費時間（時間の書かれていない手はゼロ値）
+	Comments      []Comment         // "*" で始まるコメント
+}
+
+// MoveTime は1手の消費時間と、その手までの累計。
+type MoveTime struct {
+	Move  time.Duration
+	Total time.Duration
+}
+
+// Comment は "*" のコメント1行。Ply は直前の手の手数（開始局面へのコメントは 0）。
+type Comment struct {
+	Ply  int
+	Text string
+}
+
+// ParseError は読めなかった行とその理由。
+type ParseError struct {
+	Line int // 1 始まり
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// Options は r を書き戻すための KIFOptions を返す。
+func (r *Record) Options() KIFOptions {
+	opt := DefaultKIFOptions()
+	if r.Meta.HeaderComment != "" {
+		opt.HeaderComment = r.Meta.HeaderComment
+	}
+	opt.Ending = r.Meta.Ending
+	opt.BlackName = r.Meta.BlackName
+	opt.WhiteName = r.Meta.WhiteName
+	opt.EndTime = r.Meta.EndTime
+	return opt
+}
+
+// kifBlanks は行末・ヘッダの値から取り除く空白（Kifu for Windows などは全角空白で埋める）。
+const kifBlanks = " \t\u3000"
+
+var (
+	// 行末の「+」は、この手に分岐（変化：）があるしるし
+	reMoveLine = regexp.MustCompile(`^\s*(\d+)\s+(\S+?)\s*(?:\(\s*(\d+):(\d+)/(\d+):(\d+):(\d+)\))?\s*\+?$`)
+	reEndLine  = regexp.MustCompile(`^まで(\d+)手で(.+)$`)
+	// 途中図の「手数＝20　▲７六歩　まで」は先頭の数だけを読む
+	reStartPly = regexp.MustCompile(`^手数＝(\d+)`)
+	reFrom     = regexp.MustCompile(`^\((\d)(\d)\)$`)
+	reKI2Mark  = regexp.MustCompile(`^\s*[▲△☗☖]`)
+	reKI2Token = regexp.MustCompile(`[▲△☗☖][^▲△☗☖]*`)
+)
+
+// 指し手の駒名（長いものから照合する）。成駒の名前は動かす前から成っていた駒。
+var moveNames = []struct {
+	name string
+	kind domain.PieceKind
+	prom bool
+}{
+	{"成香", 'L', true}, {"成桂", 'N', true}, {"成銀", 'S', true},
+	{"歩", 'P', false}, {"香", 'L', false}, {"桂", 'N', false}, {"銀", 'S', false},
+	{"金", 'G', false}, {"角", 'B', false}, {"飛", 'R', false}, {"玉", 'K', false}, {"王", 'K', false},
+	{"と", 'P', true}, {"杏", 'L', true}, {"圭", 'N', true}, {"全", 'S', true},
+	{"馬", 'B', true}, {"龍", 'R', true}, {"竜", 'R', true},
+}
+
+// 盤面図の駒（1文字）
+var boardGlyphs = map[rune]domain.Piece{
+	'歩': {Kind: 'P'}, '香': {Kind: 'L'}, '桂': {Kind: 'N'}, '銀': {Kind: 'S'},
+	'金': {Kind: 'G'}, '角': {Kind: 'B'}, '飛': {Kind: 'R'}, '玉': {Kind: 'K'}, '王': {Kind: 'K'},
+	'と': {Kind: 'P', Prom: true}, '杏': {Kind: 'L', Prom: true}, '圭': {Kind: 'N', Prom: true},
+	'全': {Kind: 'S', Prom: true}, '馬': {Kind: 'B', Prom: true}, '龍': {Kind: 'R', Prom: true},
+	'竜': {Kind: 'R', Prom: true},
+}
+
+var handKinds = map[rune]domain.PieceKind{
+	'歩': 'P', '香': 'L', '桂': 'N', '銀': 'S', '金': 'G', '角': 'B', '飛': 'R',
+}
+
+// 指し手の欄に書かれる終局（特殊な指し手）
+var specialMoves = map[string]domain.Ending{
+	"中断": domain.EndingNone, "投了": domain.EndingResign, "詰み": domain.EndingCheckmate,
+	"千日手": domain.EndingSennichite, "持将棋": domain.EndingJishogi,
+	"入玉勝ち": domain.EndingDeclarationWin, "反則負け": domain.EndingIllegalMove,
+}
+
+// Parse は KIF のテキストを読む。読めない行があれば *ParseError を返す。
+func Parse(text string) (*Record, error) {
+	r, _, err := parse(text, false)
+	return r, err
+}
+
+// ki2Token は KI2 の指し手1つ（▲/△ から次の ▲/△ の前まで）と、その行番号。
+type ki2Token struct {
+	text string
+	line int
+}
+
+// parse は KIF/KI2 共通の読み込み。ki2 なら ▲/△ で始まる行を指し手として集めて返す（局面に当てるのは呼び出し側）。
+func parse(text string, ki2 bool) (*Record, []ki2Token, error) {
+	text = strings.TrimPrefix(text, "\uFEFF")
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	r := &Record{Meta: Metadata{Headers: map[string]string{}}}
+	hands := domain.NewHands()
+	var board *[10][10]*domain.Piece
+	boardFiles := 0
+	side := domain.Color(0)
+	startPly := 0
+	var prevTo *domain.Square
+	var tokens []ki2Token
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], kifBlanks)
+		lineNo := i + 1
+		fail := func(format string, a ...interface{}) (*Record, []ki2Token, error) {
+			return nil, nil, &ParseError{Line: lineNo, Err: fmt.Errorf(format, a...)}
+		}
+
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#"):
+			if r.Meta.HeaderComment == "" && len(r.Moves) == 0 && len(r.Meta.Headers) == 0 {
+				r.Meta.HeaderComment = line
+			}
+			continue
+		case strings.HasPrefix(line, "*"):
+			r.Meta.Comments = append(r.Meta.Comments, Comment{Ply: len(r.Moves) + len(tokens), Text: strings.TrimPrefix(line, "*")})
+			continue
+		case strings.HasPrefix(line, "変化："):
+			// 分岐は読まない（本譜だけを読む）
+			i = len(lines)
+			continue
+		case strings.HasPrefix(line, "手数----"):
+			continue
+		case line == "先手番" || line == "下手番":
+			side = domain.Black
+			continue
+		case line == "後手番" || line == "上手番":
+			side = domain.White
+			continue
+		case strings.HasPrefix(line, "手数＝"):
+			m := reStartPly.FindStringSubmatch(line)
+			if m == nil {
+				return fail("bad 手数: %q", line)
+			}
+			n, err := strconv.Atoi(m[1])
+			if err != nil {
+				return fail("bad 手数: %q", line)
+			}
+			startPly = n
+			continue
+		case strings.HasPrefix(line, "  ") && board == nil && len(r.Moves) == 0 && isFileHeader(line):
+			// 盤面図：筋の見出し、枠、各段、枠
+			boardFiles = len(strings.Fields(line))
+			b, n, err := parseBoard(lines[i+1:], boardFiles)
+			if err != nil {
+				return nil, nil, &ParseError{Line: lineNo + 1 + n, Err: err}
+			}
+			board = b
+			i += n
+			continue
+		}
+
+		if m := reEndLine.FindStringSubmatch(line); m != nil {
+			r.Meta.EndWords = m[2]
+			r.Meta.Ending = endingFromWords(m[2])
+			continue
+		}
+		if ki2 && reKI2Mark.MatchString(line) {
+			for _, tok := range reKI2Token.FindAllString(line, -1) {
+				body := strings.Trim(tok, kifBlanks)
+				word := strings.TrimLeft(body, "▲△☗☖")
+				if e, ok := specialMoves[word]; ok {
+					r.Meta.EndWords = word
+					r.Meta.Ending = e
+					continue
+				}
+				tokens = append(tokens, ki2Token{text: body, line: lineNo})
+			}
+			continue
+		}
+		if m := reMoveLine.FindStringSubmatch(line); !ki2 && m != nil {
+			body := m[2]
+			if e, ok := specialMoves[body]; ok {
+				r.Meta.EndWords = body
+				r.Meta.Ending = e
+				continue
+			}
+			mv, err := parseMoveBody(body, prevTo)
+			if err != nil {
+				return fail("%v", err)
+			}
+			r.Moves = append(r.Moves, mv)
+			r.Meta.Times = append(r.Meta.Times, parseTime(m[3:]))
+			to := mv.To
+			prevTo = &to
+			continue
+		}
+		if key, val, ok := strings.Cut(line, "："); ok {
+			key, val = strings.Trim(key, kifBlanks), strings.Trim(val, kifBlanks)
+			switch key {
+			case "先手の持駒", "下手の持駒":
+				h, err := parseHand(val)
+				if err != nil {
+					return fail("%v", err)
+				}
+				hands[domain.Black] = h
+			case "後手の持駒", "上手の持駒":
+				h, err := parseHand(val)
+				if err != nil {
+					return fail("%v", err)
+				}
+				hands[domain.White] = h
+			case "先手", "下手":
+				r.Meta.BlackName = val
+				r.Meta.Headers[key] = val
+			case "後手", "上手":
+				r.Meta.WhiteName = val
+				r.Meta.Headers[key] = val
+			case "終了日時":
+				r.Meta.EndTime = val
+				r.Meta.Headers[key] = val
+			default:
+				r.Meta.Headers[key] = val
+			}
+			continue
+		}
+		return fail("unrecognized line: %q", line)
+	}
+
+	start, err := buildStart(r.Meta.Headers, board, boardFiles, hands, side)
+	if err != nil {
+		return nil, nil, err
+	}
+	start.StartPly = startPly
+	r.Start = start
+	return r, tokens, nil
+}
+
+// buildStart はヘッダと盤面図から開始局面を作る。盤面図があればそれを使い、無ければ手合割の初期配置にする。
+func buildStart(headers map[string]string, board *[10][10]*domain.Piece, files int, hands domain.Hands, side domain.Color) (domain.Snapshot, error) {
+	name := headers["手合割"]
+	var st *domain.State
+	switch {
+	case board != nil:
+		st = domain.NewStateEmpty()
+		st.Board = *board
+		if files == domain.VariantMinishogi.Files || name == domain.VariantMinishogi.Name {
+			st.Variant = domain.VariantMinishogi
+		}
+		if side == 0 {
+			side = domain.Black
+		}
+	case name == domain.VariantMinishogi.Name:
+		st = domain.NewStateMinishogi()
+	case name == "" || name == "詰将棋":
+		st = domain.NewStateEmpty()
+	default:
+		h, ok := domain.HandicapByName(name)
+		if !ok {
+			return domain.Snapshot{}, fmt.Errorf("unknown 手合割 without a board diagram: %s", name)
+		}
+		st = domain.NewStateHandicap(h)
+	}
+	if side != 0 {
+		st.SideToMove = side
+	}
+	if c, ok := domain.ConditionByName(headers["条件"]); ok {
+		st.Condition = c
+	}
+	st.Hands = hands
+	return st.CloneSnapshot(), nil
+}
+
+// isFileHeader は盤面図の筋の見出し（"  ９ ８ … １"）かを返す。
+func isFileHeader(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return false
+	}
+	for _, f := range fields {
+		if _, ok := fwDigitValue[f]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+var fwDigitValue = func() map[string]int {
+	m := map[string]int{}
+	for n, s := range fwDigits {
+		m[s] = n
+	}
+	return m
+}()
+
+// parseBoard は筋の見出しの次の行（上の枠）から下の枠までを読み、読んだ行数を返す。
+// エラーのときの行数は、lines の中でのエラーの行の位置。
+func parseBoard(lines []string, files int) (*[10][10]*domain.Piece, int, error) {
+	var b [10][10]*domain.Piece
+	if len(lines) == 0 || !strings.HasPrefix(lines[0], "+") {
+		return nil, 0, fmt.Errorf("board: missing top border")
+	}
+	rank := 0
+	for n := 1; n < len(lines); n++ {
+		line := strings.TrimRight(lines[n], " \t\r")
+		if strings.HasPrefix(line, "+") {
+			if rank != files {
+				return nil, n, fmt.Errorf("board: %d ranks for %d files", rank, files)
+			}
+			return &b, n + 1, nil
+		}
+		rs := []rune(line)
+		if len(rs) < 2+files*2 || rs[0] != '|' || rs[1+files*2] != '|' {
+			return nil, n, fmt.Errorf("board: bad row: %q", line)
+		}
+		rank++
+		if rank > 9 {
+			return nil, n, fmt.Errorf("board: too many ranks")
+		}
+		for c := 0; c < files; c++ {
+			mark, glyph := rs[1+c*2], rs[2+c*2]
+			if glyph == '・' {
+				continue
+			}
+			p, ok := boardGlyphs[glyph]
+			if !ok {
+				return nil, n, fmt.Errorf("board: unknown piece %q", string(glyph))
+			}
+			p.Color = domain.Black
+			if mark == 'v' {
+				p.Color = domain.White
+			}
+			b[files-c][rank] = &p
+		}
+	}
+	return nil, len(lines), fmt.Errorf("board: missing bottom border")
+}
+
+// parseHand は「飛二　角　歩十八　」の形の持駒を読む（「なし」は空）。
+func parseHand(s string) (map[domain.PieceKind]int, error) {
+	h := map[domain.PieceKind]int{}
+	s = strings.TrimSpace(strings.ReplaceAll(s, "　", " "))
+	if s == "" || s == "なし" {
+		return h, nil
+	}
+	for _, tok := range strings.Fields(s) {
+		rs := []rune(tok)
+		k, ok := handKinds[rs[0]]
+		if !ok {
+			return nil, fmt.Errorf("hand: unknown piece %q", tok)
+		}
+		n, ok := kanjiCount(string(rs[1:]))
+		if !ok {
+			return nil, fmt.Errorf("hand: bad count %q", tok)
+		}
+		h[k] += n
+	}
+	return h, nil
+}
+
+var kanjiDigit = map[rune]int{'一': 1, '二': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+
+// kanjiCount は InvCountKanji の逆（"" は 1、"十八" は 18）。
+func kanjiCount(s string) (int, bool) {
+	if s == "" {
+		return 1, true
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return n, true
+	}
+	rs := []rune(s)
+	n := 0
+	if rs[0] == '十' {
+		n = 10
+		rs = rs[1:]
+	}
+	switch len(rs) {
+	case 0:
+		return n, n > 0
+	case 1:
+		d, ok := kanjiDigit[rs[0]]
+		return n + d, ok
+	}
+	return 0, false
+}
+
+// parseMoveBody は「７六歩(77)」「同　銀(22)」「２二角成(88)」「５五金打」などを読む。
+func parseMoveBody(body string, prevTo *domain.Square) (domain.Move, error) {
+	var mv domain.Move
+	rest := body
+	switch {
+	case strings.HasPrefix(rest, "同"):
+		if prevTo == nil {
+			return mv, fmt.Errorf("同 without a previous move: %q", body)
+		}
+		mv.To = *prevTo
+		rest = strings.TrimLeft(strings.TrimPrefix(rest, "同"), "　 ")
+	default:
+		rs := []rune(rest)
+		if len(rs) < 2 {
+			return mv, fmt.Errorf("bad move: %q", body)
+		}
+		f, okF := fwDigitValue[string(rs[0])]
+		r, okR := kanjiDigit[rs[1]]
+		if !okF || !okR || f == 0 {
+			return mv, fmt.Errorf("bad square in move: %q", body)
+		}
+		mv.To = domain.Square{File: f, Rank: r}
+		rest = string(rs[2:])
+	}
+
+	found := false
+	for _, n := range moveNames {
+		if strings.HasPrefix(rest, n.name) {
+			mv.Kind = n.kind
+			mv.WasPromoted = n.prom
+			rest = strings.TrimPrefix(rest, n.name)
+			found = true
+			break
+		}
+	}
+	if !found {
+		return mv, fmt.Errorf("unknown piece in move: %q", body)
+	}
+
+	switch {
+	case strings.HasPrefix(rest, "打"):
+		if mv.WasPromoted {
+			return mv, fmt.Errorf("promoted piece dropped: %q", body)
+		}
+		mv.IsDrop = true
+		rest = strings.TrimPrefix(rest, "打")
+	case strings.HasPrefix(rest, "不成"):
+		rest = strings.TrimPrefix(rest, "不成")
+	case strings.HasPrefix(rest, "成"):
+		mv.Promote = true
+		rest = strings.TrimPrefix(rest, "成")
+	}
+
+	if mv.IsDrop {
+		if rest != "" {
+			return mv, fmt.Errorf("bad drop: %q", body)
+		}
+		return mv, nil
+	}
+	m := reFrom.FindStringSubmatch(rest)
+	if m == nil {
+		return mv, fmt.Errorf("missing from-square: %q", body)
+	}
+	f, _ := strconv.Atoi(m[1])
+	r, _ := strconv.Atoi(m[2])
+	mv.From = &domain.Square{File: f, Rank: r}
+	return mv, nil
+}
+
+// parseTime は「(0:01/00:00:01)」の各数字（無ければ空）を MoveTime にする。
+func parseTime(m []string) MoveTime {
+	if m[0] == "" {
+		return MoveTime{}
+	}
+	n := make([]int, len(m))
+	for i, s := range m {
+		n[i], _ = strconv.Atoi(s)
+	}
+	return MoveTime{
+		Move:  time.Duration(n[0])*time.Minute + time.Duration(n[1])*time.Second,
+		Total: time.Duration(n[2])*time.Hour + time.Duration(n[3])*time.Minute + time.Duration(n[4])*time.Second,
+	}
+}
+
+// endingFromWords は終了行の語（詰み・協力詰 など）から終局を返す。
+func endingFromWords(words string) domain.Ending {
+	if _, ok := domain.ConditionByName(words); ok {
+		return domain.EndingConditionMate
+	}
+	// 「反則負け」は宣言の反則と共通の語なので、宣言とは決めずに一般の反則負けとする
+	if e, ok := specialMoves[words]; ok && e != domain.EndingNone {
+		return e
+	}
+	for e, w := range endingWords {
+		if w == words && e != domain.EndingNone {
+			return e
+		}
+	}
+	return domain.EndingNone
+}
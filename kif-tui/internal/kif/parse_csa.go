@@ -0,0 +1,288 @@
+package kif
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"kif-tui/internal/domain"
+)
+
+// CSA の読み込み。1行に「,」で区切って複数の文を書けるので、文ごとに読む。
+// 開始局面は PI（平手・駒落ち）、P1〜P9（盤面）、P+/P-（持駒・駒の配置・00AL）で組み立て、
+// 手番の行（+ / -）のあとの指し手を ApplyMoveStrict で1手ずつ指す。
+
+// csaSpecials は % で始まる終局。ここに無いもの（%TIME_UP など）は EndWords だけを残す。
+var csaSpecials = map[string]domain.Ending{
+	"%CHUDAN":       domain.EndingNone,
+	"%TORYO":        domain.EndingResign,
+	"%TSUMI":        domain.EndingCheckmate,
+	"%SENNICHITE":   domain.EndingSennichite,
+	"%KACHI":        domain.EndingDeclarationWin,
+	"%JISHOGI":      domain.EndingJishogi,
+	"%ILLEGAL_MOVE": domain.EndingIllegalMove,
+}
+
+// csaKinds は CSA の2文字から駒種と成りを引く。
+var csaKinds = func() map[string]domain.Piece {
+	m := map[string]domain.Piece{}
+	for k, names := range csaPieces {
+		m[names[0]] = domain.Piece{Kind: k}
+		if names[1] != "" {
+			m[names[1]] = domain.Piece{Kind: k, Prom: true}
+		}
+	}
+	return m
+}()
+
+// ParseCSA は CSA のテキストを読み、各手を開始局面から ApplyMoveStrict で指す。
+// 読めない文があれば *ParseError を返す。指せない手があれば、その手の前までの Record と
+// 行番号つきの *ParseError（中身は手数つきの *domain.ReplayError）を返す。
+// $ の行は「$」と「:」を除いたキー（EVENT など）で Meta.Headers に入れる。
+func ParseCSA(text string) (*Record, error) {
+	text = strings.TrimPrefix(text, "\uFEFF")
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	r := &Record{Meta: Metadata{Headers: map[string]string{}}}
+	st := domain.NewStateEmpty()
+	started := false // 手番の行まで読んだ（以降は指し手）
+	var total [2]time.Duration
+	lastLine := 0 // 最後に文があった行（手番の行が無いときに報告する）
+
+	for i, raw := range lines {
+		lineNo := i + 1
+		fail := func(format string, a ...interface{}) (*Record, error) {
+			return nil, &ParseError{Line: lineNo, Err: fmt.Errorf(format, a...)}
+		}
+
+		line := strings.TrimRight(raw, " \t")
+		if line != "" {
+			lastLine = lineNo
+		}
+		if strings.HasPrefix(line, "'") {
+			// コメント（GenerateCSA が書く「'条件：」は条件として読む）
+			text := strings.TrimPrefix(line, "'")
+			if name, ok := strings.CutPrefix(text, "条件："); ok && !started {
+				if c, ok := domain.ConditionByName(name); ok {
+					st.Condition = c
+					continue
+				}
+			}
+			r.Meta.Comments = append(r.Meta.Comments, Comment{Ply: len(st.Moves), Text: text})
+			continue
+		}
+
+		for _, stmt := range strings.Split(line, ",") {
+			switch {
+			case stmt == "":
+			case stmt[0] == 'V':
+				// バージョン（V2.2 など）
+			case strings.HasPrefix(stmt, "N+"):
+				r.Meta.BlackName = stmt[2:]
+			case strings.HasPrefix(stmt, "N-"):
+				r.Meta.WhiteName = stmt[2:]
+			case stmt[0] == '$':
+				k, v, ok := strings.Cut(stmt[1:], ":")
+				if !ok {
+					return fail("bad header: %q", stmt)
+				}
+				r.Meta.Headers[k] = v
+			case stmt[0] == '%':
+				r.Meta.EndWords = stmt
+				r.Meta.Ending = csaSpecials[stmt]
+			case stmt[0] == 'T' && started:
+				sec, err := strconv.Atoi(stmt[1:])
+				if err != nil || sec < 0 || len(st.Moves) == 0 || len(r.Meta.Times) != len(st.Moves)-1 {
+					return fail("bad time: %q", stmt)
+				}
+				// 累計は指した側ごとに数える
+				d := time.Duration(sec) * time.Second
+				mover := len(st.Moves) % 2
+				total[mover] += d
+				r.Meta.Times = append(r.Meta.Times, MoveTime{Move: d, Total: total[mover]})
+			case stmt[0] == 'P' && !started:
+				if err := parseCSAPosition(st, stmt); err != nil {
+					return fail("%v", err)
+				}
+			case (stmt == "+" || stmt == "-") && !started:
+				st.SideToMove = csaSide(stmt[0])
+				st.ClearHistory()
+				r.Start = st.CloneSnapshot()
+				started = true
+			case (stmt[0] == '+' || stmt[0] == '-') && started:
+				mv, err := parseCSAMove(st, stmt)
+				if err == nil {
+					err = st.ApplyMoveStrict(mv.Kind, mv.From, mv.To, mv.Promote, mv.IsDrop)
+				}
+				if err != nil {
+					r.Moves = st.Moves
+					return r, &ParseError{Line: lineNo, Err: &domain.ReplayError{Ply: len(st.Moves) + 1, Move: mv, Err: err}}
+				}
+				// 時間の無い手のために、T の前の手はゼロ値で埋める
+				for len(r.Meta.Times) < len(st.Moves)-1 {
+					r.Meta.Times = append(r.Meta.Times, MoveTime{})
+				}
+			default:
+				return fail("unexpected statement: %q", stmt)
+			}
+		}
+	}
+	if !started {
+		return nil, &ParseError{Line: lastLine, Err: fmt.Errorf("no side to move (+ or -) after the position")}
+	}
+	r.Moves = st.Moves
+	for len(r.Meta.Times) < len(r.Moves) {
+		r.Meta.Times = append(r.Meta.Times, MoveTime{})
+	}
+	return r, nil
+}
+
+// CSAOptions は r を CSA で書き戻すための CSAOptions を返す。
+func (r *Record) CSAOptions() CSAOptions {
+	opt := CSAOptions{
+		BlackName: r.Meta.BlackName,
+		WhiteName: r.Meta.WhiteName,
+		Event:     r.Meta.Headers["EVENT"],
+		StartTime: r.Meta.Headers["START_TIME"],
+		Ending:    r.Meta.Ending,
+	}
+	for _, t := range r.Meta.Times {
+		opt.Times = append(opt.Times, t.Move)
+	}
+	return opt
+}
+
+// parseCSAPosition は開始局面の文（PI…, P1〜P9, P+/P-）を st に当てる。
+func parseCSAPosition(st *domain.State, stmt string) error {
+	switch {
+	case strings.HasPrefix(stmt, "PI"):
+		// 平手から、続く「82HI」などの駒を取り除く（先に読んだ条件などは残す）
+		hirate := domain.NewStateHirate()
+		st.Board, st.Hands = hirate.Board, hirate.Hands
+		items := stmt[2:]
+		if len(items)%4 != 0 {
+			return fmt.Errorf("bad PI: %q", stmt)
+		}
+		for j := 0; j < len(items); j += 4 {
+			sq, ok := csaSquareOf(items[j : j+2])
+			p := st.PieceAt(sq)
+			if !ok || p == nil || csaPieces[p.Kind][0] != items[j+2:j+4] {
+				return fmt.Errorf("bad PI piece: %q", items[j:j+4])
+			}
+			st.SetPieceAt(sq, nil)
+		}
+		return nil
+
+	case strings.HasPrefix(stmt, "P+"), strings.HasPrefix(stmt, "P-"):
+		// 「00KI」は持駒、「00AL」は残りの駒すべてを持駒に、「5355FU」のようなマスは盤上に置く
+		c := csaSide(stmt[1])
+		items := stmt[2:]
+		if len(items)%4 != 0 {
+			return fmt.Errorf("bad %s: %q", stmt[:2], stmt)
+		}
+		for j := 0; j < len(items); j += 4 {
+			code := items[j+2 : j+4]
+			if items[j:j+2] == "00" && code == "AL" {
+				for k, n := range st.Box() {
+					if k != 'K' && n > 0 {
+						st.Hands[c][k] += n
+					}
+				}
+				continue
+			}
+			p, ok := csaKinds[code]
+			if !ok {
+				return fmt.Errorf("unknown piece: %q", code)
+			}
+			if items[j:j+2] == "00" {
+				if p.Prom || p.Kind == 'K' {
+					return fmt.Errorf("bad piece in hand: %q", code)
+				}
+				st.Hands[c][p.Kind]++
+				continue
+			}
+			sq, ok := csaSquareOf(items[j : j+2])
+			if !ok {
+				return fmt.Errorf("bad square: %q", items[j:j+2])
+			}
+			p.Color = c
+			st.SetPieceAt(sq, &p)
+		}
+		return nil
+
+	case len(stmt) >= 2 && stmt[1] >= '1' && stmt[1] <= '9':
+		// P1〜P9：9筋から1筋へ3文字ずつ（" * " は空き）。行末の空白は省かれていてもよい
+		rank := int(stmt[1] - '0')
+		cells := stmt[2:]
+		if len(cells) > 27 {
+			return fmt.Errorf("bad board row: %q", stmt)
+		}
+		cells += strings.Repeat(" ", 27-len(cells))
+		for j := 0; j < 9; j++ {
+			cell := cells[3*j : 3*j+3]
+			sq := domain.Square{File: 9 - j, Rank: rank}
+			if strings.TrimSpace(cell) == "*" || strings.TrimSpace(cell) == "" {
+				st.SetPieceAt(sq, nil)
+				continue
+			}
+			p, ok := csaKinds[cell[1:]]
+			if !ok || (cell[0] != '+' && cell[0] != '-') {
+				return fmt.Errorf("bad board cell %q in %q", cell, stmt)
+			}
+			p.Color = csaSide(cell[0])
+			st.SetPieceAt(sq, &p)
+		}
+		return nil
+	}
+	return fmt.Errorf("unexpected statement: %q", stmt)
+}
+
+// parseCSAMove は「+7776FU」「-0055KA」を st の局面の domain.Move にする。
+// 駒は動いた後の名前なので、移動元の駒が成っていなければ成る手とみなす。
+func parseCSAMove(st *domain.State, stmt string) (domain.Move, error) {
+	if len(stmt) != 7 {
+		return domain.Move{}, fmt.Errorf("bad move: %q", stmt)
+	}
+	if csaSide(stmt[0]) != st.SideToMove {
+		return domain.Move{}, fmt.Errorf("move out of turn: %q", stmt)
+	}
+	to, okTo := csaSquareOf(stmt[3:5])
+	p, okP := csaKinds[stmt[5:7]]
+	if !okTo || !okP {
+		return domain.Move{}, fmt.Errorf("bad move: %q", stmt)
+	}
+	if stmt[1:3] == "00" {
+		if p.Prom {
+			return domain.Move{}, fmt.Errorf("cannot drop a promoted piece: %q", stmt)
+		}
+		return domain.Move{IsDrop: true, Kind: p.Kind, To: to}, nil
+	}
+	from, ok := csaSquareOf(stmt[1:3])
+	if !ok {
+		return domain.Move{}, fmt.Errorf("bad move: %q", stmt)
+	}
+	mv := domain.Move{Kind: p.Kind, From: &from, To: to}
+	if cur := st.PieceAt(from); cur != nil {
+		if cur.Prom && !p.Prom {
+			return mv, fmt.Errorf("promoted piece cannot unpromote: %q", stmt)
+		}
+		mv.Promote = p.Prom && !cur.Prom
+	}
+	return mv, nil
+}
+
+// csaSquareOf は「76」のような2桁を盤上のマスにする（00 や盤外は ok=false）。
+func csaSquareOf(s string) (domain.Square, bool) {
+	if len(s) != 2 || s[0] < '1' || s[0] > '9' || s[1] < '1' || s[1] > '9' {
+		return domain.Square{}, false
+	}
+	return domain.Square{File: int(s[0] - '0'), Rank: int(s[1] - '0')}, true
+}
+
+func csaSide(b byte) domain.Color {
+	if b == '-' {
+		return domain.White
+	}
+	return domain.Black
+}
@@ -0,0 +1,186 @@
+package kif
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"kif-tui/internal/domain"
+)
+
+func TestParseCSA_RoundTripGoldens(t *testing.T) {
+	// [csa-roundtrip]
+	// 目的：testdata の各 CSA ゴールデンを読み、GenerateCSA で書き戻すと一字一句同じになること。
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.golden.csa"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no goldens: %v", err)
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec, err := ParseCSA(string(want))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			got, err := GenerateCSA(rec.Start, rec.Moves, rec.CSAOptions())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Fatalf("round-trip mismatch.\n--- got ---\n%s\n--- want ---\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestParseCSA_External(t *testing.T) {
+	// [csa-external]
+	// 目的：他のソフトが書く形（CRLF・コメント・「,」区切り・駒落ちの PI・T 行・%TORYO）を読めること。
+	text := "'CSA encoding=UTF-8\r\n" +
+		"V2.2\r\n" +
+		"N+下手\r\n" +
+		"N-上手\r\n" +
+		"$EVENT:駒落ち研究会\r\n" +
+		"PI22KA\r\n" +
+		"-\r\n" +
+		"-8384FU,T10\r\n" +
+		"+7776FU,T3,-8485FU\r\n" +
+		"'角道を止める\r\n" +
+		"+8877KA,T2\r\n" +
+		"%TORYO\r\n"
+	rec, err := ParseCSA(text)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if rec.Meta.BlackName != "下手" || rec.Meta.WhiteName != "上手" || rec.Meta.Headers["EVENT"] != "駒落ち研究会" {
+		t.Fatalf("meta = %+v", rec.Meta)
+	}
+	if h, ok := domain.DetectHandicap(rec.Start); !ok || h != domain.HandicapBishop || rec.Start.SideToMove != domain.White {
+		t.Fatalf("start is not 角落ち with 上手 to move")
+	}
+	if len(rec.Moves) != 4 || rec.Meta.Ending != domain.EndingResign || rec.Meta.EndWords != "%TORYO" {
+		t.Fatalf("moves=%d ending=%v words=%q", len(rec.Moves), rec.Meta.Ending, rec.Meta.EndWords)
+	}
+	// 3手目には T が無い（ゼロ値）。累計は指した側ごと
+	wantTimes := []MoveTime{
+		{10 * time.Second, 10 * time.Second}, {3 * time.Second, 3 * time.Second},
+		{}, {2 * time.Second, 5 * time.Second},
+	}
+	for i, want := range wantTimes {
+		if rec.Meta.Times[i] != want {
+			t.Fatalf("time[%d] = %+v, want %+v", i, rec.Meta.Times[i], want)
+		}
+	}
+	wantComments := []Comment{{Ply: 0, Text: "CSA encoding=UTF-8"}, {Ply: 3, Text: "角道を止める"}}
+	if len(rec.Meta.Comments) != 2 || rec.Meta.Comments[0] != wantComments[0] || rec.Meta.Comments[1] != wantComments[1] {
+		t.Fatalf("comments = %+v", rec.Meta.Comments)
+	}
+}
+
+func TestParseCSA_PositionStatements(t *testing.T) {
+	// [csa-position]
+	// 目的：P1〜P9 の行（行末の空白なし）・P+ のマス指定と持駒・P-00AL で局面を組み立て、成る手を読めること。
+	text := "P1 *  *  *  * -OU\n" +
+		"P+53FU33GI00KI\n" +
+		"P-00AL\n" +
+		"+\n" +
+		"+3342NG\n"
+	rec, err := ParseCSA(text)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	sq := func(f, r int) domain.Square { return domain.Square{File: f, Rank: r} }
+	if p := rec.Start.Board[5][1]; p == nil || p.Kind != 'K' || p.Color != domain.White {
+		t.Fatalf("5一 = %+v", p)
+	}
+	if p := rec.Start.Board[3][3]; p == nil || p.Kind != 'S' || p.Color != domain.Black {
+		t.Fatalf("3三 = %+v", p)
+	}
+	if rec.Start.Hands[domain.Black]['G'] != 1 || rec.Start.Hands[domain.Black]['P'] != 0 {
+		t.Fatalf("black hand = %v", rec.Start.Hands[domain.Black])
+	}
+	// 残りの駒（玉を除く）は後手の持駒：歩は盤上の1枚を除く17枚、金は3枚
+	if rec.Start.Hands[domain.White]['P'] != 17 || rec.Start.Hands[domain.White]['G'] != 3 || rec.Start.Hands[domain.White]['K'] != 0 {
+		t.Fatalf("white hand = %v", rec.Start.Hands[domain.White])
+	}
+	if mv := rec.Moves[0]; !mv.Promote || mv.To != sq(4, 2) {
+		t.Fatalf("move = %+v", mv)
+	}
+}
+
+func TestParseCSA_ConditionBeforePI(t *testing.T) {
+	// [csa-condition-before-pi]
+	// 目的：PI より前の「'条件：」コメントが、PI で局面を作り直しても残ること。
+	rec, err := ParseCSA("V2.2\n'条件：協力詰\nPI\n+\n")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if rec.Start.Condition != domain.ConditionHelpmate {
+		t.Fatalf("condition = %v, want 協力詰", rec.Start.Condition)
+	}
+	if h, ok := domain.DetectHandicap(rec.Start); !ok || h != domain.HandicapNone {
+		t.Fatalf("start is not 平手")
+	}
+}
+
+func TestParseCSA_IllegalMoveEnding(t *testing.T) {
+	// [csa-illegal-move]
+	// 目的：%ILLEGAL_MOVE は入玉宣言の反則ではなく一般の反則負けとして読み、そのまま書き戻せること。
+	text := "PI\n+\n+7776FU,T1\n%ILLEGAL_MOVE\n"
+	rec, err := ParseCSA(text)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if rec.Meta.Ending != domain.EndingIllegalMove {
+		t.Fatalf("ending = %v, want EndingIllegalMove", rec.Meta.Ending)
+	}
+	got, err := GenerateCSA(rec.Start, rec.Moves, rec.CSAOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "+7776FU\nT1\n%ILLEGAL_MOVE\n"; !strings.HasSuffix(got, want) {
+		t.Fatalf("output does not end with %q:\n%s", want, got)
+	}
+}
+
+func TestParseCSA_Errors(t *testing.T) {
+	// [csa-errors]
+	// 目的：読めない文・指せない手は行番号つきの ParseError になり、指せない手は手数と手前までの手順を返すこと。
+	tests := []struct {
+		name string
+		text string
+		line int
+	}{
+		{"bad-cell", "P1 *  * +XX\n+\n", 1},
+		{"bad-pi", "PI22HI\n+\n", 1},
+		{"bad-time", "PI\n+\nT5\n", 3},
+		{"no-side", "PI\n", 1},
+		{"unknown", "PI\n+\nhello\n", 3},
+	}
+	for _, tc := range tests {
+		_, err := ParseCSA(tc.text)
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Fatalf("%s: err=%v, want ParseError", tc.name, err)
+		}
+		if pe.Line != tc.line {
+			t.Fatalf("%s: line=%d want %d (%v)", tc.name, pe.Line, tc.line, err)
+		}
+	}
+
+	rec, err := ParseCSA("PI\n+\n+7776FU\n-3334FU,+7675FU,-8255HI\n")
+	var pe *ParseError
+	var re *domain.ReplayError
+	if !errors.As(err, &pe) || pe.Line != 4 || !errors.As(err, &re) || re.Ply != 4 {
+		t.Fatalf("err = %v, want line 4 / ply 4", err)
+	}
+	if rec == nil || len(rec.Moves) != 3 {
+		t.Fatalf("partial record = %+v", rec)
+	}
+}
@@ -0,0 +1,188 @@
+package kif
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"kif-tui/internal/domain"
+)
+
+// KI2 の読み込み。KI2 の指し手には移動元が無いので、開始局面から1手ずつ指しながら
+// 右・左・直・上・引・寄・打 で動かす駒を決める。ヘッダ・盤面図・終了行は KIF と同じに読む。
+
+var (
+	// ErrAmbiguousMove は、表記に合う駒が複数あって動かす駒を決められない手を表す。
+	ErrAmbiguousMove = errors.New("ambiguous move")
+	// ErrNoSuchMove は、表記に合う駒が無い手を表す。
+	ErrNoSuchMove = errors.New("no piece can make this move")
+)
+
+// ki2Spec は KI2 の指し手1つを読んだもの（まだ局面に当てていない）。
+type ki2Spec struct {
+	to        domain.Square
+	kind      domain.PieceKind
+	prom      bool   // 成駒の名前（成銀・馬 など）で書かれていた
+	qual      string // 右・左・直・上・引・寄
+	promote   bool   // 成
+	drop      bool   // 打
+	noPromote bool   // 不成・生
+}
+
+// ParseKI2 は KI2 のテキストを読み、各手を局面に当てて domain.Move にする。
+// 決められない手・指せない手があれば、その手の前までの Record と、
+// 行番号つきの *ParseError（中身は手数つきの *domain.ReplayError）を返す。
+func ParseKI2(text string) (*Record, error) {
+	r, tokens, err := parse(text, true)
+	if err != nil {
+		return nil, err
+	}
+
+	st := domain.NewStateEmpty()
+	st.RestoreSnapshot(r.Start)
+	st.ClearHistory()
+
+	var prevTo *domain.Square
+	for i, tok := range tokens {
+		mv, err := resolveKI2(st, tok.text, prevTo)
+		if err == nil {
+			err = st.ApplyMoveStrict(mv.Kind, mv.From, mv.To, mv.Promote, mv.IsDrop)
+		}
+		if err != nil {
+			r.Moves = st.Moves
+			return r, &ParseError{Line: tok.line, Err: &domain.ReplayError{Ply: i + 1, Move: mv, Err: err}}
+		}
+		to := mv.To
+		prevTo = &to
+	}
+	r.Moves = st.Moves
+	return r, nil
+}
+
+// resolveKI2 は st の局面で手番側が指す KI2 の指し手 tok（▲/△ つき）を domain.Move にする。
+func resolveKI2(st *domain.State, tok string, prevTo *domain.Square) (domain.Move, error) {
+	spec, err := parseKI2Body(strings.TrimLeft(tok, "▲△☗☖"), prevTo)
+	if err != nil {
+		return domain.Move{}, err
+	}
+	side := st.SideToMove
+	if spec.drop {
+		if spec.prom || spec.qual != "" || spec.promote || spec.noPromote {
+			return domain.Move{}, fmt.Errorf("bad drop: %s", tok)
+		}
+		return domain.Move{IsDrop: true, Kind: spec.kind, To: spec.to}, nil
+	}
+
+	cands := ki2Candidates(st, spec.kind, spec.prom, spec.to)
+	if len(cands) == 0 {
+		// 盤上から動ける駒が無ければ「打」を省いた打ち
+		if !spec.prom && spec.qual == "" && !spec.promote && !spec.noPromote && st.Hands[side][spec.kind] > 0 {
+			return domain.Move{IsDrop: true, Kind: spec.kind, To: spec.to}, nil
+		}
+		return domain.Move{}, fmt.Errorf("%w: %s", ErrNoSuchMove, tok)
+	}
+
+	matched := make([]domain.Square, 0, len(cands))
+	for _, from := range cands {
+		if ki2Matches(side, from, spec.to, spec.qual, cands) {
+			matched = append(matched, from)
+		}
+	}
+	switch len(matched) {
+	case 0:
+		return domain.Move{}, fmt.Errorf("%w: %s", ErrNoSuchMove, tok)
+	case 1:
+	default:
+		return domain.Move{}, fmt.Errorf("%w: %s", ErrAmbiguousMove, tok)
+	}
+	from := matched[0]
+	return domain.Move{Kind: spec.kind, From: &from, To: spec.to, Promote: spec.promote}, nil
+}
+
+// ki2Matches は from が qual（右・左・直・上・引・寄 の組み合わせ）に合うかを返す。
+// 左・右は、動作も書かれていれば同じ動作の駒の中で、無ければ cands 全体の中で見る。
+func ki2Matches(side domain.Color, from, to domain.Square, qual string, cands []domain.Square) bool {
+	motion := ki2Motion(side, from, to)
+	set := cands
+	for _, q := range []string{"上", "引", "寄"} {
+		if !strings.Contains(qual, q) {
+			continue
+		}
+		if motion != q {
+			return false
+		}
+		set = set[:0:0]
+		for _, c := range cands {
+			if ki2Motion(side, c, to) == q {
+				set = append(set, c)
+			}
+		}
+	}
+	if strings.Contains(qual, "直") && (from.File != to.File || motion != "上") {
+		return false
+	}
+	for _, q := range []string{"左", "右"} {
+		if strings.Contains(qual, q) && ki2Horizontal(side, from, to, set, true) != q {
+			return false
+		}
+	}
+	return true
+}
+
+// parseKI2Body は「７六歩」「同　銀」「２二角成」「５八金右」「５五角打」などを読む。
+func parseKI2Body(body string, prevTo *domain.Square) (ki2Spec, error) {
+	var spec ki2Spec
+	rest := body
+	if strings.HasPrefix(rest, "同") {
+		if prevTo == nil {
+			return spec, fmt.Errorf("同 without a previous move: %q", body)
+		}
+		spec.to = *prevTo
+		rest = strings.TrimLeft(strings.TrimPrefix(rest, "同"), "　 ")
+	} else {
+		rs := []rune(rest)
+		if len(rs) < 2 {
+			return spec, fmt.Errorf("bad move: %q", body)
+		}
+		f, okF := fwDigitValue[string(rs[0])]
+		r, okR := kanjiDigit[rs[1]]
+		if !okF || !okR || f == 0 {
+			return spec, fmt.Errorf("bad square in move: %q", body)
+		}
+		spec.to = domain.Square{File: f, Rank: r}
+		rest = string(rs[2:])
+	}
+
+	found := false
+	for _, n := range moveNames {
+		if strings.HasPrefix(rest, n.name) {
+			spec.kind, spec.prom = n.kind, n.prom
+			rest = strings.TrimPrefix(rest, n.name)
+			found = true
+			break
+		}
+	}
+	if !found {
+		return spec, fmt.Errorf("unknown piece in move: %q", body)
+	}
+
+	for _, q := range []string{"右", "左", "直", "上", "引", "寄"} {
+		if strings.HasPrefix(rest, q) {
+			spec.qual += q
+			rest = strings.TrimPrefix(rest, q)
+		}
+	}
+
+	switch rest {
+	case "":
+	case "打":
+		spec.drop = true
+	case "成":
+		spec.promote = true
+	case "不成", "生":
+		spec.noPromote = true
+	default:
+		return spec, fmt.Errorf("bad move: %q", body)
+	}
+	return spec, nil
+}
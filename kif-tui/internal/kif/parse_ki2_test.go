@@ -0,0 +1,123 @@
+package kif
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"kif-tui/internal/domain"
+)
+
+func TestParseKI2_RoundTripGoldens(t *testing.T) {
+	// [ki2-parse-roundtrip]
+	// 目的：KI2 のゴールデンを読み、GenerateKI2 で書き戻すと一字一句同じになること。
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.golden.ki2"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no goldens: %v", err)
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec, err := ParseKI2(string(want))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			got, err := GenerateKI2(rec.Start, rec.Moves, rec.Options())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Fatalf("round-trip mismatch.\n--- got ---\n%s\n--- want ---\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestParseKI2_ResolvesWrittenMoves(t *testing.T) {
+	// [ki2-parse-resolve]
+	// 目的：平手から決まった規則で選んだ手順について、KI2Move の表記を resolveKI2 で読むと元の手に戻ること。
+	st := domain.NewStateHirate()
+	var prevTo *domain.Square
+	for ply := 1; ply <= 120; ply++ {
+		legal := st.LegalMoves()
+		if len(legal) == 0 {
+			break
+		}
+		mv := legal[(ply*37)%len(legal)]
+		if !mv.IsDrop {
+			if p := st.PieceAt(*mv.From); p != nil {
+				mv.WasPromoted = p.Prom
+			}
+		}
+		text := KI2Move(st, mv, prevTo)
+		got, err := resolveKI2(st, text, prevTo)
+		if err != nil {
+			t.Fatalf("ply %d: %s: %v", ply, text, err)
+		}
+		if got.IsDrop != mv.IsDrop || got.To != mv.To || got.Promote != mv.Promote ||
+			(!mv.IsDrop && *got.From != *mv.From) {
+			t.Fatalf("ply %d: %s resolved to %+v, want %+v", ply, text, got, mv)
+		}
+		if err := st.ApplyMoveStrict(mv.Kind, mv.From, mv.To, mv.Promote, mv.IsDrop); err != nil {
+			t.Fatalf("ply %d: apply: %v", ply, err)
+		}
+		to := mv.To
+		prevTo = &to
+	}
+}
+
+func TestParseKI2_FullWidthSeparators(t *testing.T) {
+	// [ki2-parse-fullwidth]
+	// 目的：指し手を全角空白で区切った KI2（よくある書き方）を読めること。
+	rec, err := ParseKI2("手合割：平手\n▲７六歩　△３四歩　▲２六歩　\n△投了\n")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(rec.Moves) != 3 || rec.Moves[2].To != (domain.Square{File: 2, Rank: 6}) {
+		t.Fatalf("moves = %+v", rec.Moves)
+	}
+	if rec.Meta.Ending != domain.EndingResign {
+		t.Fatalf("ending = %v, want EndingResign", rec.Meta.Ending)
+	}
+}
+
+func TestParseKI2_Errors(t *testing.T) {
+	// [ki2-parse-errors]
+	// 目的：決められない手・指せない手は、行番号と手数つきのエラーになり、その前までの手順が返ること。
+	tests := []struct {
+		name   string
+		text   string
+		target error
+		line   int
+		ply    int
+		prefix int
+	}{
+		{"ambiguous", "手合割：平手\n▲７六歩    △３四歩\n▲５八金\n", ErrAmbiguousMove, 3, 3, 2},
+		{"no-such-piece", "手合割：平手\n▲７六歩    △３四歩    ▲５五飛\n", ErrNoSuchMove, 2, 3, 2},
+		{"wrong-qualifier", "手合割：平手\n▲５八金直\n", ErrNoSuchMove, 2, 1, 0},
+		{"occupied-drop", "手合割：平手\n▲７六歩    △３四歩    ▲２二角    △同　銀    ▲１一角\n", domain.ErrDropOccupied, 2, 5, 4},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec, err := ParseKI2(tc.text)
+			if !errors.Is(err, tc.target) {
+				t.Fatalf("err=%v, want %v", err, tc.target)
+			}
+			var pe *ParseError
+			var re *domain.ReplayError
+			if !errors.As(err, &pe) || !errors.As(err, &re) {
+				t.Fatalf("err=%T, want ParseError wrapping ReplayError", err)
+			}
+			if pe.Line != tc.line || re.Ply != tc.ply {
+				t.Fatalf("line=%d ply=%d, want line=%d ply=%d", pe.Line, re.Ply, tc.line, tc.ply)
+			}
+			if rec == nil || len(rec.Moves) != tc.prefix {
+				t.Fatalf("partial record has %v moves, want %d", rec, tc.prefix)
+			}
+		})
+	}
+}
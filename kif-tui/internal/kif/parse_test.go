@@ -0,0 +1,219 @@
+package kif
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/japanese"
+
+	"kif-tui/internal/domain"
+)
+
+func TestParse_RoundTripGoldens(t *testing.T) {
+	// [parse-roundtrip]
+	// 目的：testdata の各ゴールデンを読み、GenerateKIF で書き戻すと一字一句同じになること。
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.golden.kif"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no goldens: %v", err)
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rec, err := Parse(string(want))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			got, err := GenerateKIF(rec.Start, rec.Moves, rec.Options())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Fatalf("round-trip mismatch.\n--- got ---\n%s\n--- want ---\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestParse_ExternalKIF(t *testing.T) {
+	// [parse-external]
+	// 目的：他のソフトが書く形（BOM・CRLF・「同　」・不成・コメント・消費時間・投了）を読めること。
+	text := "\uFEFF# KIF形式棋譜ファイル\r\n" +
+		"開始日時：2024/01/02 10:00:00\r\n" +
+		"手合割：平手\r\n" +
+		"先手：羽生\r\n" +
+		"後手：藤井\r\n" +
+		"手数----指手---------消費時間--\r\n" +
+		"*開始局面へのコメント\r\n" +
+		"   1 ７六歩(77)   ( 0:05/00:00:05)\r\n" +
+		"   2 ３四歩(33)   ( 1:02/00:01:02)\r\n" +
+		"*角交換へ\r\n" +
+		"   3 ２二角成(88)   ( 0:03/00:00:08)\r\n" +
+		"   4 同　銀(31)   ( 0:02/00:01:04)\r\n" +
+		"   5 ４五角打   ( 0:10/00:00:18)\r\n" +
+		"   6 ５二金(61)   ( 0:01/00:01:05)\r\n" +
+		"   7 ６三角不成(45)   ( 0:01/00:00:19)\r\n" +
+		"   8 投了\r\n"
+	rec, err := Parse(text)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if rec.Meta.HeaderComment != "# KIF形式棋譜ファイル" || rec.Meta.BlackName != "羽生" || rec.Meta.WhiteName != "藤井" {
+		t.Fatalf("meta = %+v", rec.Meta)
+	}
+	if rec.Meta.Headers["開始日時"] != "2024/01/02 10:00:00" {
+		t.Fatalf("開始日時 = %q", rec.Meta.Headers["開始日時"])
+	}
+	if rec.Meta.EndWords != "投了" {
+		t.Fatalf("EndWords = %q", rec.Meta.EndWords)
+	}
+	if len(rec.Moves) != 7 {
+		t.Fatalf("moves = %d, want 7", len(rec.Moves))
+	}
+	if mv := rec.Moves[3]; mv.To != (domain.Square{File: 2, Rank: 2}) || mv.Kind != 'S' {
+		t.Fatalf("同　銀 = %+v", mv)
+	}
+	if !rec.Moves[2].Promote || rec.Moves[6].Promote || !rec.Moves[4].IsDrop {
+		t.Fatalf("promote/drop flags wrong: %+v", rec.Moves)
+	}
+	if got := rec.Meta.Times[1]; got.Move != 62*time.Second || got.Total != 62*time.Second {
+		t.Fatalf("time[1] = %+v", got)
+	}
+	wantComments := []Comment{{Ply: 0, Text: "開始局面へのコメント"}, {Ply: 2, Text: "角交換へ"}}
+	if len(rec.Meta.Comments) != 2 || rec.Meta.Comments[0] != wantComments[0] || rec.Meta.Comments[1] != wantComments[1] {
+		t.Fatalf("comments = %+v", rec.Meta.Comments)
+	}
+	if _, rerr := domain.Replay(rec.Start, rec.Moves, true); rerr != nil {
+		t.Fatalf("replay: %v", rerr)
+	}
+}
+
+func TestParse_FullWidthPadding(t *testing.T) {
+	// [parse-fullwidth-padding]
+	// 目的：Kifu for Windows のように全角空白で埋めたヘッダ・行末を読めること。
+	text := "手合割：平手　　\n" +
+		"先手：羽生　\n" +
+		"後手：　藤井\n" +
+		"手数----指手---------消費時間--　\n" +
+		"   1 ７六歩(77)   ( 0:01/00:00:01)　\n"
+	rec, err := Parse(text)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if rec.Meta.Headers["手合割"] != "平手" || rec.Meta.BlackName != "羽生" || rec.Meta.WhiteName != "藤井" {
+		t.Fatalf("meta = %+v", rec.Meta)
+	}
+	if len(rec.Moves) != 1 {
+		t.Fatalf("moves = %d, want 1", len(rec.Moves))
+	}
+}
+
+func TestParse_VariationsSkipped(t *testing.T) {
+	// [parse-variations]
+	// 目的：分岐のしるし「+」の付いた手を読み、「変化：」以降の分岐は読み飛ばして本譜だけを返すこと。
+	text := "手合割：平手\n" +
+		"手数----指手---------消費時間--\n" +
+		"   1 ７六歩(77)   ( 0:00/00:00:00)+\n" +
+		"   2 ３四歩(33)   ( 0:00/00:00:00)\n" +
+		"   3 ２六歩(27)+\n" +
+		"\n" +
+		"変化：1手\n" +
+		"   1 ２六歩(27)   ( 0:00/00:00:00)\n" +
+		"\n" +
+		"変化：3手\n" +
+		"   3 ６六歩(67)   ( 0:00/00:00:00)\n"
+	rec, err := Parse(text)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(rec.Moves) != 3 || rec.Moves[0].To != (domain.Square{File: 7, Rank: 6}) || rec.Moves[2].To != (domain.Square{File: 2, Rank: 6}) {
+		t.Fatalf("moves = %+v", rec.Moves)
+	}
+}
+
+func TestParse_IllegalMoveEnding(t *testing.T) {
+	// [parse-illegal-move]
+	// 目的：指し手の欄・終了行の「反則負け」を、入玉宣言の反則ではなく一般の反則負けとして読むこと。
+	for _, text := range []string{
+		"手合割：平手\n   1 ７六歩(77)\n   2 反則負け\n",
+		"手合割：平手\n   1 ７六歩(77)\nまで1手で反則負け\n",
+	} {
+		rec, err := Parse(text)
+		if err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		if rec.Meta.Ending != domain.EndingIllegalMove {
+			t.Fatalf("ending = %v, want EndingIllegalMove (%q)", rec.Meta.Ending, text)
+		}
+	}
+}
+
+func TestParse_MidgameDiagramPly(t *testing.T) {
+	// [parse-midgame-ply]
+	// 目的：途中図の「手数＝20　▲７六歩　まで」から手数だけを読み、続く手順を21手目から読めること。
+	data, err := os.ReadFile(filepath.Join("testdata", "midgame-diagram.kif"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec, err := Parse(string(data))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if rec.Start.StartPly != 20 || rec.Start.SideToMove != domain.White || len(rec.Moves) != 3 {
+		t.Fatalf("start ply=%d side=%v moves=%d", rec.Start.StartPly, rec.Start.SideToMove, len(rec.Moves))
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	// [decode-text]
+	// 目的：Shift_JIS の棋譜を UTF-8 に直して読めること、どちらでもないバイト列は ErrEncoding になること。
+	text := "手合割：平手\r\n先手：羽生\r\n   1 ７六歩(77)   ( 0:01/00:00:01)\r\n"
+	sjis, err := japanese.ShiftJIS.NewEncoder().String(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := DecodeText([]byte(sjis))
+	if err != nil || got != text {
+		t.Fatalf("DecodeText(sjis) = %q, %v", got, err)
+	}
+	if got, err := DecodeText([]byte(text)); err != nil || got != text {
+		t.Fatalf("DecodeText(utf8) = %q, %v", got, err)
+	}
+	if _, err := DecodeText([]byte{0x93, 0xfd, 0xff, 0xfe}); !errors.Is(err, ErrEncoding) {
+		t.Fatalf("err = %v, want ErrEncoding", err)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	// [parse-errors]
+	// 目的：読めない行は行番号つきの ParseError になること。
+	tests := []struct {
+		name string
+		text string
+		line int
+	}{
+		{"bad-move", "手合割：平手\n   1 ７六歩(77)\n   2 ３十歩(33)\n", 3},
+		{"same-first", "手合割：平手\n   1 同歩(77)\n", 2},
+		{"bad-hand", "手合割：詰将棋\n先手の持駒：象\n", 2},
+		{"bad-board", "手合割：詰将棋\n  ９ ８ ７ ６ ５ ４ ３ ２ １\n+---------------------------+\n| ・ ・ ・ ・ 象 ・ ・ ・ ・|一\n", 4},
+		{"unknown-line", "手合割：平手\nこんにちは\n", 2},
+	}
+	for _, tc := range tests {
+		_, err := Parse(tc.text)
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Fatalf("%s: err=%v, want ParseError", tc.name, err)
+		}
+		if pe.Line != tc.line {
+			t.Fatalf("%s: line=%d want %d (%v)", tc.name, pe.Line, tc.line, err)
+		}
+	}
+	if _, err := Parse("手合割：平手\n"); err != nil {
+		t.Fatalf("header-only parse: %v", err)
+	}
+}
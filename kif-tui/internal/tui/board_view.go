@@ -7,23 +7,30 @@ import (
 )
 
 // RenderBoard renders current position (m.st) in a fixed-width grid.
-// Coordinate: [File 9..1] x [Rank 1..9] (KIF-style).
+// Coordinate: [File 9..1] x [Rank 1..9] (KIF-style); smaller variants use their own size.
 // We intentionally keep it plain and stable for UX/readability.
 func RenderBoard(st *domain.State, cursor domain.Square, placementOn bool, next domain.Piece) string {
 	// Files header: ９..１
 	// Use ASCII digits for now to avoid width issues; we keep columns aligned.
 	// You can switch to full-width digits later if you prefer.
+	v := st.Rules()
+	files := make([]string, 0, v.Files)
+	for f := v.Files; f >= 1; f-- {
+		files = append(files, string(rune('0'+f)))
+	}
+	border := "  +" + strings.Repeat("-", v.Files*2+1) + "+\n"
+
 	var b strings.Builder
-	b.WriteString("    9 8 7 6 5 4 3 2 1\n")
-	b.WriteString("  +-------------------+\n")
+	b.WriteString("    " + strings.Join(files, " ") + "\n")
+	b.WriteString(border)
 
-	for r := 1; r <= 9; r++ {
+	for r := 1; r <= v.Ranks; r++ {
 		// Rank label on the left
 		b.WriteString(" ")
 		b.WriteByte(byte('0' + r))
 		b.WriteString("|")
 
-		for f := 9; f >= 1; f-- {
+		for f := v.Files; f >= 1; f-- {
 			sq := domain.Square{File: f, Rank: r}
 			p := st.PieceAt(sq)
 			isCursor := (sq == cursor)
@@ -32,7 +39,7 @@ func RenderBoard(st *domain.State, cursor domain.Square, placementOn bool, next
 		b.WriteString("|\n")
 	}
 
-	b.WriteString("  +-------------------+\n")
+	b.WriteString(border)
 	return b.String()
 }
 
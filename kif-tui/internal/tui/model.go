@@ -1,7 +1,10 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -64,6 +67,12 @@ type Model struct {
 
 	// hand edit
 	handEditKind domain.PieceKind
+
+	// 宣言・千日手などで決まった終局（EndingNone=対局中）。終局後は指せない
+	ending domain.Ending
+
+	// 反則メッセージの言語（lang ja|en）
+	lang domain.Lang
 }
 
 // numeric input (7776 / 77761 / 076)
@@ -348,12 +357,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					kind := domain.PieceKind(m.pickerItems[m.pickerIdx][0])
 					to := m.pickerDropTo
 					if err := m.st.ApplyMoveStrict(kind, nil, to, false, true); err != nil {
-						m.appendLog(fmt.Sprintf("drop failed: %v", err))
+						m.logMoveError("drop", err)
 						m.closePicker("")
 						return m, nil
 					}
 					m.appendLog(fmt.Sprintf("drop %c to %v", kind, to))
 					m.closePicker("")
+					m.checkRepetition()
 					return m, nil
 
 				default:
@@ -383,14 +393,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					m.appendLog("hand edit invalid: " + err.Error())
 					return m, nil
 				}
-				if err := validateHandCount(m.handEditKind, b, w); err != nil {
+				// 駒箱から出し入れする（一組の枚数を超える指定はエラー）
+				if err := m.st.SetHandCounts(m.handEditKind, b, w); err != nil {
 					m.appendLog("hand edit invalid: " + err.Error())
 					return m, nil
 				}
 
-				m.st.Hands[domain.Black][m.handEditKind] = b
-				m.st.Hands[domain.White][m.handEditKind] = w
-
 				// EDIT中は常に先手番固定
 				if !m.inPlay() {
 					m.st.SideToMove = domain.Black
@@ -419,22 +427,33 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m *Model) moveCursor(df, dr int) {
 	f := m.cursor.File + df
 	r := m.cursor.Rank + dr
-	if f < 1 || f > 9 || r < 1 || r > 9 {
+	if !m.st.Rules().Contains(domain.Square{File: f, Rank: r}) {
 		return
 	}
 	m.cursor = domain.Square{File: f, Rank: r}
 }
 
+// clampCursor は盤が小さくなったときカーソルを盤内に戻す。
+func (m *Model) clampCursor() {
+	v := m.st.Rules()
+	m.cursor.File = min(m.cursor.File, v.Files)
+	m.cursor.Rank = min(m.cursor.Rank, v.Ranks)
+}
+
 func (m *Model) placeAtCursor() {
 	if m.inPlay() {
 		return
 	}
-	p := &domain.Piece{
+	p := domain.Piece{
 		Color: m.place.Color,
 		Kind:  m.place.Kind,
 		Prom:  m.place.Promote,
 	}
-	m.st.SetPieceAt(m.cursor, p)
+	// 駒箱から取り出して置く（残っていなければ置かない）
+	if err := m.st.PlaceFromBox(m.cursor, p); err != nil {
+		m.appendLog("place failed: " + err.Error())
+		return
+	}
 
 	// 配置後だけリセット（空マスでは next を保持）
 	m.place.Color = domain.Black
@@ -464,32 +483,145 @@ func (m *Model) execCommand(line string) {
 
 	switch parts[0] {
 	case "start":
+		// start [b|w] [N]：手番（既定は先手、駒落ちの初期局面なら上手）と、開始局面までの手数（既定 0）
+		side := domain.Black
+		if h, ok := domain.DetectHandicap(m.st.CloneSnapshot()); ok {
+			side = h.FirstMover()
+		}
+		startPly := 0
+		for _, a := range parts[1:] {
+			switch a {
+			case "b":
+				side = domain.Black
+			case "w":
+				side = domain.White
+			default:
+				n, err := strconv.Atoi(a)
+				if err != nil || n < 0 {
+					m.appendLog("usage: start [b|w] [N]")
+					return
+				}
+				startPly = n
+			}
+		}
+
+		// 開始前に局面を検査する（エラーがあれば開始しない）
+		m.st.SideToMove = side
+		issues := m.st.Validate()
+		for _, is := range issues {
+			level := "WARN"
+			if is.Severity == domain.SeverityError {
+				level = "ERROR"
+			}
+			m.appendLog(fmt.Sprintf("%s: %s", level, is.Message))
+		}
+		if domain.HasErrors(issues) {
+			m.st.SideToMove = domain.Black
+			m.appendLog("start refused: fix the position in EDIT mode")
+			return
+		}
+
+		m.st.Moves = nil
+		m.st.StartPly = startPly
 		snap := m.st.CloneSnapshot()
 		m.startSnapshot = &snap
-		m.st.Moves = nil
-		// PLAY開始時は必ず先手番から
-		m.st.SideToMove = domain.Black
-		m.appendLog("game started (PLAY)")
+		// 開始局面を undo / 千日手判定の起点にする
+		m.st.ClearHistory()
+		m.ending = domain.EndingNone
+		mark := "▲"
+		if side == domain.White {
+			mark = "▽"
+		}
+		m.appendLog(fmt.Sprintf("game started (PLAY) %s %d", mark, m.st.Ply()))
 
 	case "setup":
-		m.st = domain.NewStateHirate()
+		// setup [手合割]：平手（既定）・駒落ち・5五将棋の初期局面
+		if len(parts) > 1 && (parts[1] == "mini" || parts[1] == domain.VariantMinishogi.Name) {
+			m.st = domain.NewStateMinishogi()
+			m.startSnapshot = nil
+			m.ending = domain.EndingNone
+			m.clampCursor()
+			m.appendLog(fmt.Sprintf("setup %s (EDIT)", domain.VariantMinishogi.Name))
+			return
+		}
+		h := domain.HandicapNone
+		if len(parts) > 1 {
+			var ok bool
+			if h, ok = parseHandicap(parts[1]); !ok {
+				m.appendLog("usage: setup [hirate|kyo|rkyo|kaku|hisha|hikyo|2mai|4mai|6mai|8mai|10mai|mini]")
+				return
+			}
+		}
+		m.st = domain.NewStateHandicap(h)
 		m.startSnapshot = nil
+		m.ending = domain.EndingNone
+		// EDIT中は先手番固定（駒落ちの上手番は start で決める）
 		m.st.SideToMove = domain.Black
-		m.appendLog("setup hirate (EDIT)")
+		m.appendLog(fmt.Sprintf("setup %s (EDIT)", h.Name()))
 
 	case "clear", "new", "reset":
+		// 盤の種類（5五将棋など）と条件はそのまま空にする
+		v, c := m.st.Variant, m.st.Condition
 		m.st = domain.NewStateEmpty()
+		m.st.Variant = v
+		m.st.Condition = c
 		m.startSnapshot = nil
+		m.ending = domain.EndingNone
 		m.st.SideToMove = domain.Black
 		m.appendLog("cleared (EDIT)")
 
-	case "kif":
+	case "cond":
+		m.execCondition(parts[1:])
+
+	case "mirror", "flip", "shift":
+		m.execTransform(parts[0], parts[1:])
+
+	case "declare":
+		m.execDeclare(parts[1:])
+
+	case "undo", "redo":
+		m.execUndoRedo(parts[0])
+
+	case "lang":
+		if len(parts) < 2 || (parts[1] != "ja" && parts[1] != "en") {
+			m.appendLog("usage: lang ja|en")
+			return
+		}
+		m.lang = domain.LangJA
+		if parts[1] == "en" {
+			m.lang = domain.LangEN
+		}
+		m.appendLog("lang: " + parts[1])
+
+	case "load":
+		if len(parts) < 2 {
+			m.appendLog("usage: load <file.kif|.ki2|.csa>")
+			return
+		}
+		m.execLoad(strings.Join(parts[1:], " "))
+
+	case "kif", "ki2", "csa":
 		start := m.startSnapshot
 		if start == nil {
 			s := m.st.CloneSnapshot()
 			start = &s
 		}
-		out := kif.GenerateKIF(*start, m.st.Moves, kif.DefaultKIFOptions())
+		opt := kif.DefaultKIFOptions()
+		opt.Ending = m.ending
+		var out string
+		var err error
+		switch parts[0] {
+		case "ki2":
+			out, err = kif.GenerateKI2(*start, m.st.Moves, opt)
+		case "csa":
+			out, err = kif.GenerateCSA(*start, m.st.Moves, kif.CSAOptions{Ending: m.ending})
+		default:
+			out, err = kif.GenerateKIF(*start, m.st.Moves, opt)
+		}
+		if err != nil {
+			m.appendLog(parts[0] + ": " + err.Error())
+			return
+		}
 		m.kifPreview = strings.TrimRight(out, "\n")
 
 		if m.kifVPReady {
@@ -497,7 +629,7 @@ func (m *Model) execCommand(line string) {
 			m.kifViewport.GotoTop()
 		}
 
-		m.appendLog("KIF updated")
+		m.appendLog(strings.ToUpper(parts[0]) + " updated")
 
 	default:
 		m.appendLog(fmt.Sprintf("unknown command: %s", parts[0]))
@@ -509,10 +641,14 @@ func (m *Model) execNumeric(s string) {
 		m.appendLog("not in PLAY. use start first.")
 		return
 	}
+	if m.ending != domain.EndingNone {
+		m.appendLog("game is over. use undo or start/reset to continue.")
+		return
+	}
 
 	tag, from, to, promote, err := domain.ParseNumeric(s)
 	if err != nil {
-		m.appendLog(fmt.Sprintf("invalid numeric: %v", err))
+		m.appendLog("invalid numeric: " + domain.Localize(err, m.lang))
 		return
 	}
 
@@ -529,10 +665,11 @@ func (m *Model) execNumeric(s string) {
 		}
 		kind := cands[0]
 		if err := m.st.ApplyMoveStrict(kind, nil, to, false, true); err != nil {
-			m.appendLog(fmt.Sprintf("drop failed: %v", err))
+			m.logMoveError("drop", err)
 			return
 		}
 		m.appendLog(fmt.Sprintf("drop %c to %v", kind, to))
+		m.checkRepetition()
 		return
 
 	case "move":
@@ -547,10 +684,11 @@ func (m *Model) execNumeric(s string) {
 		}
 		kind := p.Kind
 		if err := m.st.ApplyMoveStrict(kind, from, to, promote, false); err != nil {
-			m.appendLog(fmt.Sprintf("move failed: %v", err))
+			m.logMoveError("move", err)
 			return
 		}
 		m.appendLog(fmt.Sprintf("move %v->%v promote=%v", *from, to, promote))
+		m.checkRepetition()
 		return
 
 	default:
@@ -559,6 +697,254 @@ func (m *Model) execNumeric(s string) {
 	}
 }
 
+// execLoad: KIF/KI2/CSA ファイル（拡張子 .ki2 なら KI2、.csa なら CSA）を読み込み、開始局面から手順を指し直して PLAY にする。
+// 途中で反則の手・決められない手があれば、その手の前までを読み込む。
+func (m *Model) execLoad(path string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		m.appendLog("load failed: " + err.Error())
+		return
+	}
+	// Shift_JIS の棋譜も読めるように文字列へ直す
+	text, err := kif.DecodeText(data)
+	if err != nil {
+		m.appendLog("load failed: " + err.Error())
+		return
+	}
+	var rec *kif.Record
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ki2":
+		rec, err = kif.ParseKI2(text)
+	case ".csa":
+		rec, err = kif.ParseCSA(text)
+	default:
+		rec, err = kif.Parse(text)
+	}
+	if rec == nil {
+		m.appendLog("load failed: " + err.Error())
+		return
+	}
+	if err != nil {
+		// KI2/CSA は指せない手の前までを読み込む
+		m.appendLog("load: " + err.Error())
+	}
+	st, rerr := domain.Replay(rec.Start, rec.Moves, true)
+	m.st = st
+	start := rec.Start
+	m.startSnapshot = &start
+	m.ending = domain.EndingNone
+	m.clampCursor()
+	if rerr != nil {
+		m.appendLog(fmt.Sprintf("load: stopped at %s", domain.Localize(rerr, m.lang)))
+		return
+	}
+	// 宣言などで明示された終局は引き継ぐ（詰みは局面から判る。千日手は下の checkRepetition で決める）
+	switch rec.Meta.Ending {
+	case domain.EndingDeclarationWin, domain.EndingJishogi, domain.EndingIllegalDeclaration, domain.EndingResign,
+		domain.EndingIllegalMove:
+		m.ending = rec.Meta.Ending
+	}
+	if m.ending == domain.EndingNone {
+		m.checkRepetition()
+	}
+	m.appendLog(fmt.Sprintf("loaded %s (%d moves, PLAY)", path, len(st.Moves)))
+}
+
+// execTransform: EDIT 局面の変形（mirror：左右反転 / flip：先後反転 / shift df dr：平行移動）
+func (m *Model) execTransform(cmd string, args []string) {
+	if m.inPlay() {
+		m.appendLog("cannot edit in PLAY (use clear/reset)")
+		return
+	}
+	switch cmd {
+	case "mirror":
+		m.st.MirrorFiles()
+	case "flip":
+		m.st.FlipColors()
+		// EDIT中は先手番固定（手番は start で決める）
+		m.st.SideToMove = domain.Black
+	case "shift":
+		// shift df dr：正の df で筋の数が増える方向（盤の左）、正の dr で段の数が増える方向（盤の下）
+		if len(args) != 2 {
+			m.appendLog("usage: shift <files> <ranks>")
+			return
+		}
+		df, err1 := strconv.Atoi(args[0])
+		dr, err2 := strconv.Atoi(args[1])
+		if err1 != nil || err2 != nil {
+			m.appendLog("usage: shift <files> <ranks>")
+			return
+		}
+		if err := m.st.Shift(df, dr); err != nil {
+			m.appendLog("shift rejected: " + domain.Localize(err, m.lang))
+			return
+		}
+	}
+	m.appendLog(cmd + " (EDIT)")
+}
+
+// conditionAliases: cond で使える条件の短い名前
+var conditionAliases = map[string]domain.Condition{
+	"none":     domain.ConditionNone,
+	"help":     domain.ConditionHelpmate,
+	"self":     domain.ConditionSelfmate,
+	"helpself": domain.ConditionHelpSelfmate,
+}
+
+// execCondition: フェアリー詰将棋の条件（cond [none|help|self|helpself]、名前でも可）
+func (m *Model) execCondition(args []string) {
+	if len(args) == 0 {
+		name := m.st.Condition.Name()
+		if name == "" {
+			name = "none"
+		}
+		m.appendLog("cond: " + name)
+		return
+	}
+	if m.inPlay() {
+		m.appendLog("cannot change cond in PLAY (use clear/reset)")
+		return
+	}
+	c, ok := conditionAliases[args[0]]
+	if !ok {
+		if c, ok = domain.ConditionByName(args[0]); !ok {
+			m.appendLog("usage: cond [none|help|self|helpself]")
+			return
+		}
+	}
+	m.st.Condition = c
+	if c == domain.ConditionNone {
+		m.appendLog("cond: none")
+		return
+	}
+	m.appendLog("cond: " + c.Name())
+}
+
+// execDeclare: 手番側の入玉宣言（declare [27|24]、既定は 27点法）
+func (m *Model) execDeclare(args []string) {
+	if !m.inPlay() {
+		m.appendLog("declare is PLAY-only. use start first.")
+		return
+	}
+	if m.ending != domain.EndingNone {
+		m.appendLog("game is already over")
+		return
+	}
+	rule := domain.DeclarationRule27
+	if len(args) > 0 {
+		switch args[0] {
+		case "27":
+			rule = domain.DeclarationRule27
+		case "24":
+			rule = domain.DeclarationRule24
+		default:
+			m.appendLog("usage: declare [27|24]")
+			return
+		}
+	}
+
+	res := m.st.EvaluateDeclaration(rule)
+	m.appendLog(fmt.Sprintf("declare: king-in-zone=%v pieces-in-zone=%d points=%d in-check=%v",
+		res.KingInZone, res.PiecesInZone, res.Points, res.InCheck))
+
+	m.ending = res.Ending
+	switch res.Ending {
+	case domain.EndingDeclarationWin:
+		m.appendLog("入玉宣言勝ち")
+	case domain.EndingJishogi:
+		m.appendLog("持将棋 (draw)")
+	default:
+		m.appendLog("宣言の条件を満たさない: 反則負け")
+	}
+}
+
+// handicapAliases: setup で使える手合割の短い名前
+var handicapAliases = map[string]domain.Handicap{
+	"hirate": domain.HandicapNone,
+	"kyo":    domain.HandicapLance,
+	"rkyo":   domain.HandicapRightLance,
+	"kaku":   domain.HandicapBishop,
+	"hisha":  domain.HandicapRook,
+	"hikyo":  domain.HandicapRookLance,
+	"2mai":   domain.HandicapTwo,
+	"4mai":   domain.HandicapFour,
+	"6mai":   domain.HandicapSix,
+	"8mai":   domain.HandicapEight,
+	"10mai":  domain.HandicapTen,
+}
+
+// parseHandicap: 短い名前か、手合割の名前（香落ち など）
+func parseHandicap(s string) (domain.Handicap, bool) {
+	if h, ok := handicapAliases[s]; ok {
+		return h, true
+	}
+	return domain.HandicapByName(s)
+}
+
+// execUndoRedo: 1手戻す / やり直す（PLAY のみ。宣言後の undo は宣言だけを取り消し、千日手の後の undo は終局を解いて1手戻す）
+func (m *Model) execUndoRedo(cmd string) {
+	if !m.inPlay() {
+		m.appendLog(cmd + " is PLAY-only. use start first.")
+		return
+	}
+	if cmd == "undo" && m.ending != domain.EndingNone {
+		repetition := m.ending == domain.EndingSennichite || m.ending == domain.EndingPerpetualCheck
+		m.ending = domain.EndingNone
+		if !repetition {
+			m.appendLog("undo: declaration cancelled")
+			return
+		}
+	}
+
+	ok := false
+	if cmd == "undo" {
+		ok = m.st.Undo()
+	} else {
+		ok = m.st.Redo()
+	}
+	if !ok {
+		m.appendLog(cmd + ": nothing to " + cmd)
+		return
+	}
+	m.appendLog(fmt.Sprintf("%s: ply=%d", cmd, len(m.st.Moves)))
+	if cmd == "redo" {
+		m.checkRepetition()
+	}
+}
+
+// logMoveError: 反則はコードごとのメッセージ（m.lang の言語）で表示する
+func (m *Model) logMoveError(what string, err error) {
+	var re *domain.RuleError
+	if errors.As(err, &re) {
+		m.appendLog(fmt.Sprintf("%s rejected: %s", what, domain.Localize(err, m.lang)))
+		return
+	}
+	m.appendLog(fmt.Sprintf("%s failed: %v", what, err))
+}
+
+// checkRepetition: 同一局面が繰り返されたら警告し、4回で千日手（連続王手の千日手）として終局にする
+func (m *Model) checkRepetition() {
+	n := m.st.RepetitionCount()
+	if n < 2 {
+		return
+	}
+	repeated, perpetual, checker := m.st.Sennichite()
+	switch {
+	case perpetual:
+		side := "▲"
+		if checker == domain.White {
+			side = "▽"
+		}
+		m.ending = domain.EndingPerpetualCheck
+		m.appendLog(fmt.Sprintf("連続王手の千日手 (%s loses). game over", side))
+	case repeated:
+		m.ending = domain.EndingSennichite
+		m.appendLog("千日手 (same position 4 times). game over")
+	default:
+		m.appendLog(fmt.Sprintf("WARNING: same position repeated %d times", n))
+	}
+}
+
 func (m *Model) openPickerPlace() {
 	m.m = modePicker
 	m.pickerOn = true
@@ -640,7 +1026,17 @@ func (m Model) View() string {
 	}
 	turnLabel := turnMark
 	if m.inPlay() {
-		turnLabel = fmt.Sprintf("%s %d", turnMark, len(m.st.Moves)+1)
+		turnLabel = fmt.Sprintf("%s %d", turnMark, m.st.Ply())
+		// 指した後の局面が詰み／王手なら表示する
+		if m.ending != domain.EndingNone {
+			turnLabel += " 終局"
+		} else if e := m.st.DetectEnding(); e == domain.EndingConditionMate {
+			turnLabel += " " + m.st.Condition.Name()
+		} else if e == domain.EndingCheckmate {
+			turnLabel += " 詰み"
+		} else if m.st.IsInCheck(m.st.SideToMove) {
+			turnLabel += " 王手"
+		}
 	} else {
 		turnLabel = fmt.Sprintf("%s EDIT", turnMark)
 	}
@@ -673,6 +1069,8 @@ func (m Model) View() string {
 		} else {
 			placeStatus = "PLACEMENT: OFF  (press P to toggle)"
 		}
+		// 駒箱の残り（玉方に渡せる駒の確認用）
+		placeStatus += "\n" + boxStatus(m.st)
 	}
 
 	// ---- left: board ----
@@ -763,10 +1161,11 @@ func pieceOptionItems() []string {
 
 func handItems(st *domain.State) []string {
 	items := make([]string, 0, len(pieceOptions))
+	box := st.Box()
 	for _, k := range pieceOptions {
 		b := st.Hands[domain.Black][k]
 		w := st.Hands[domain.White][k]
-		items = append(items, fmt.Sprintf("%c  (B:%d  W:%d)", k, b, w))
+		items = append(items, fmt.Sprintf("%c  (B:%d  W:%d  box:%d)", k, b, w, box[k]))
 	}
 	return items
 }
@@ -816,16 +1215,19 @@ func parseHandEditLine(s string) (b int, w int, err error) {
 	return b, w, nil
 }
 
-func validateHandCount(kind domain.PieceKind, b, w int) error {
-	if b < 0 || w < 0 {
-		return fmt.Errorf("counts must be >= 0")
+// boxStatus: 駒箱の中身を "BOX: R2 B2 ..." の形で返す（空なら "BOX: (empty)"）
+func boxStatus(st *domain.State) string {
+	box := st.Box()
+	parts := make([]string, 0, len(pieceOptions))
+	for _, k := range []domain.PieceKind{'K', 'R', 'B', 'G', 'S', 'N', 'L', 'P'} {
+		if box[k] > 0 {
+			parts = append(parts, fmt.Sprintf("%c%d", k, box[k]))
+		}
 	}
-	const maxAny = 18
-	if b > maxAny || w > maxAny {
-		return fmt.Errorf("too many pieces: max=%d", maxAny)
+	if len(parts) == 0 {
+		return "BOX: (empty)"
 	}
-	_ = kind
-	return nil
+	return "BOX: " + strings.Join(parts, " ")
 }
 
 func clamp(n, lo, hi int) int {